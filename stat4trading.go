@@ -1,11 +1,55 @@
 package stat4trading
 
 import (
+	"container/heap"
 	"errors"
 	"math"
+	"runtime"
+	"sort"
+	"sync"
 )
 import "fmt"
 
+// Tolerances used to compare floats throughout the package, see SetFloatTolerance.
+var (
+	floatAbsoluteTolerance = 1e-9
+	floatRelativeTolerance = 0.0
+)
+
+// selfControlTolerance is the maximum round-off error allowed by internal self-control checks.
+// It is deliberately NOT affected by SetFloatTolerance, so user settings can't make self-control fail on valid data.
+var selfControlTolerance = 1e-9
+
+// SetFloatTolerance sets tolerances used to compare floats throughout the package (e.g. to detect parallel lines,
+// coinciding X coordinates, zero variance, etc). Two values are considered equal if
+// |v1 - v2| <= max(absoluteTolerance, relativeTolerance * max(|v1|, |v2|)).
+// Default is absoluteTolerance = 1e-9 and relativeTolerance = 0 (pure absolute comparison).
+// absoluteTolerance should be positive (zero tolerance makes exact comparison, which fails on ordinary round-off errors),
+// and relativeTolerance should be in range [0, 1) (with relativeTolerance >= 1 every value would be equal to zero).
+// Relative tolerance is more appropriate for instruments priced in thousands, while for prices in fractions of a cent
+// the absolute tolerance may need to be reduced.
+// PLEASE NOTE: tolerances are package-level settings, so they should be set once at startup,
+// not concurrently with calling other functions of the package.
+func SetFloatTolerance(absoluteTolerance, relativeTolerance float64) error {
+	if absoluteTolerance <= 0 {
+		return errors.New("stat4trading::SetFloatTolerance: absolute tolerance should be positive")
+	}
+
+	if relativeTolerance < 0 || relativeTolerance >= 1 {
+		return errors.New("stat4trading::SetFloatTolerance: relative tolerance should be in range [0, 1)")
+	}
+
+	floatAbsoluteTolerance = absoluteTolerance
+	floatRelativeTolerance = relativeTolerance
+
+	return nil
+}
+
+// FloatTolerance returns tolerances currently used to compare floats, see SetFloatTolerance.
+func FloatTolerance() (absoluteTolerance, relativeTolerance float64) {
+	return floatAbsoluteTolerance, floatRelativeTolerance
+}
+
 type Numeric interface {
 	int64 | float64 | int32 | float32 | int
 }
@@ -63,6 +107,105 @@ func SMA(inputData []float64, windowWidth int, expectedOutputDataLength int) ([]
 	return processedData, nil
 }
 
+// SMAIgnoreNaN - SimpleMovingAverage which skips NaN values: every output is the average of only non-NaN values of the window,
+// and it is NaN only if ALL the values of the window are NaN. It allows to calculate SMA over data with gaps without prefilling them.
+// Windowing and output data length are the same as in SMA.
+func SMAIgnoreNaN(inputData []float64, windowWidth int) ([]float64, error) {
+	if windowWidth < 1 {
+		return nil, errors.New("stat4trading::SMAIgnoreNaN: window width should be positive")
+	}
+
+	outputDataLength := CalculateOutputDataLengthAfterMA(len(inputData), windowWidth)
+
+	if outputDataLength <= 0 {
+		return nil, errors.New("stat4trading::SMAIgnoreNaN: not enough data to calculate SMA of specified window width, increase data set or reduce window width")
+	}
+
+	processedData := make([]float64, outputDataLength)
+
+	for i := 0; i < outputDataLength; i++ {
+		sum := 0.0
+		count := 0
+
+		for j := i; j < i+windowWidth; j++ {
+			if math.IsNaN(inputData[j]) {
+				continue
+			}
+
+			sum += inputData[j]
+			count++
+		}
+
+		if count == 0 {
+			processedData[i] = math.NaN()
+			continue
+		}
+
+		processedData[i] = sum / float64(count)
+	}
+
+	return processedData, nil
+}
+
+// CenteredSMA - SimpleMovingAverage with the window centered on every point (windowWidth should be odd):
+// output[i] is the average of inputData[i - windowWidth/2 ... i + windowWidth/2], so there is no phase shift.
+// Output data length is the same as input. The first and the last windowWidth/2 points don't have full window, so they are NaN.
+// WARNING: this average uses FUTURE data, so it is suitable for offline research only and must NOT be used for live signals.
+func CenteredSMA(inputData []float64, windowWidth int) ([]float64, error) {
+	if windowWidth < 1 || windowWidth%2 == 0 {
+		return nil, errors.New("stat4trading::CenteredSMA: window width should be positive odd number")
+	}
+
+	trailingSMA, err := SMA(inputData, windowWidth, CalculateOutputDataLengthAfterMA(len(inputData), windowWidth))
+
+	if err != nil {
+		return nil, fmt.Errorf("stat4trading::CenteredSMA: %w", err)
+	}
+
+	halfWidth := windowWidth / 2
+	result := make([]float64, len(inputData))
+
+	for i := 0; i < len(inputData); i++ {
+		if i < halfWidth || i >= len(inputData)-halfWidth {
+			result[i] = math.NaN()
+			continue
+		}
+
+		// Trailing SMA of the window which starts at i-halfWidth is the centered SMA for i.
+		result[i] = trailingSMA[i-halfWidth]
+	}
+
+	return result, nil
+}
+
+// MAEnvelope calculates moving average envelope: middle is SMA(windowWidth), upper = middle * (1 + percent/100),
+// lower = middle * (1 - percent/100). Output data length is the same as after SMA.
+func MAEnvelope(inputData []float64, windowWidth int, percent float64) (upper, middle, lower []float64, err error) {
+	if windowWidth < 1 {
+		return nil, nil, nil, errors.New("stat4trading::MAEnvelope: window width should be positive")
+	}
+
+	if percent <= 0 {
+		return nil, nil, nil, errors.New("stat4trading::MAEnvelope: percent should be positive")
+	}
+
+	middle, err = SMA(inputData, windowWidth, CalculateOutputDataLengthAfterMA(len(inputData), windowWidth))
+
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("stat4trading::MAEnvelope: %w", err)
+	}
+
+	upper = make([]float64, len(middle))
+	lower = make([]float64, len(middle))
+
+	for i := 0; i < len(middle); i++ {
+		upper[i] = middle[i] * (1 + percent/100)
+		lower[i] = middle[i] * (1 - percent/100)
+	}
+
+	return upper, middle, lower, nil
+}
+
 // WMA - WeightedMovingAverage
 // expectedOutputDataLength is the required parameter for self-control.
 // It should be known BEFORE doing smoothing, and if it is calculated incorrectly you can't handle obtained result in a right way.
@@ -98,6 +241,55 @@ func WMA(inputData []float64, windowWidth int, expectedOutputDataLength int) ([]
 	return processedData, nil
 }
 
+// CustomWMA - WeightedMovingAverage with custom weights
+// Window width is defined by the length of weights. weights[0] is applied to the oldest point of the window,
+// and weights[len(weights)-1] to the most recent one (so WMA is the same as CustomWMA with weights [1, 2, 3, ... windowWidth]).
+// Weighted sum is normalized by the sum of weights.
+// expectedOutputDataLength is the required parameter for self-control.
+// It should be known BEFORE doing smoothing, and if it is calculated incorrectly you can't handle obtained result in a right way.
+func CustomWMA(inputData []float64, weights []float64, expectedOutputDataLength int) ([]float64, error) {
+	if len(weights) == 0 {
+		return nil, errors.New("stat4trading::CustomWMA: weights cannot be empty")
+	}
+
+	denominator := 0.0
+
+	for i := 0; i < len(weights); i++ {
+		denominator += weights[i]
+	}
+
+	if isAlmostEqual(denominator, 0.0) {
+		return nil, errors.New("stat4trading::CustomWMA: sum of weights cannot be zero")
+	}
+
+	windowWidth := len(weights)
+	outputDataLength := CalculateOutputDataLengthAfterMA(len(inputData), windowWidth)
+
+	if outputDataLength <= 0 {
+		return nil, errors.New("stat4trading::CustomWMA: not enough data to calculate WMA of specified window width, increase data set or reduce number of weights")
+	}
+
+	if expectedOutputDataLength != outputDataLength {
+		return nil, errors.New("stat4trading::CustomWMA: incorrectly calculated expected output data length")
+	}
+
+	processedData := make([]float64, outputDataLength)
+
+	for i := 0; i < outputDataLength; i++ {
+		windowStart := i
+		windowEnd := i + windowWidth - 1
+		sum := 0.0
+
+		for j := windowStart; j <= windowEnd; j++ {
+			sum += inputData[j] * weights[j-windowStart]
+		}
+
+		processedData[i] = sum / denominator
+	}
+
+	return processedData, nil
+}
+
 // EMA - ExponentialMovingAverage
 // expectedOutputDataLength is the required parameter for self-control.
 // It should be known BEFORE doing smoothing, and if it is calculated incorrectly you can't handle obtained result in a right way.
@@ -115,13 +307,8 @@ func EMA(inputData []float64, windowWidth int, expectedOutputDataLength int) ([]
 		return nil, errors.New("stat4trading::EMA: incorrectly calculated expected output data length")
 	}
 
-	ema := make([]float64, len(inputData))
-	ema[0] = inputData[0]
 	alpha := float64(2) / float64(1+windowWidth)
-
-	for i := 1; i < len(inputData); i++ {
-		ema[i] = alpha*inputData[i] + (1-alpha)*ema[i-1]
-	}
+	ema := calculateEMA(inputData, alpha)
 
 	result := ema[windowWidth-1:]
 
@@ -132,261 +319,4083 @@ func EMA(inputData []float64, windowWidth int, expectedOutputDataLength int) ([]
 	return result, nil
 }
 
-func Subtract(initialData []float64, deductibleData []float64) ([]float64, error) {
-	if len(initialData) != len(deductibleData) {
-		return nil, errors.New("stat4trading::Subtract: both input data sets should be the same length")
+// EMAWithAlpha - ExponentialMovingAverage with explicitly specified smoothing factor alpha (0 < alpha <= 1).
+// In contrast to EMA, the result is NOT trimmed: EMA is seeded with inputData[0], and the output is the same length as the input.
+func EMAWithAlpha(inputData []float64, alpha float64) ([]float64, error) {
+	if len(inputData) == 0 {
+		return nil, errors.New("stat4trading::EMAWithAlpha: input data set cannot be empty")
 	}
 
-	result := make([]float64, len(initialData))
-
-	for i := 0; i < len(initialData); i++ {
-		result[i] = initialData[i] - deductibleData[i]
+	if alpha <= 0 || alpha > 1 {
+		return nil, errors.New("stat4trading::EMAWithAlpha: alpha should be in range (0, 1]")
 	}
 
-	return result, nil
+	return calculateEMA(inputData, alpha), nil
 }
 
-func FindIntersectionDirections(referenceGraph []float64, investigatedGraph []float64) ([]string, error) {
-	if len(referenceGraph) != len(investigatedGraph) {
-		return nil, errors.New("stat4trading::FindIntersectionDirections: both input data sets should be the same length")
+// EMAContinue continues calculation of EMA (with alpha = 2/(1+windowWidth), the same as in EMA) from the known previous value seed,
+// which is used as ema[-1] instead of seeding with inputData[0]. Pass the last value of the previous batch as seed
+// to process a price stream in batches without discontinuities at batch boundaries.
+// In contrast to EMA, the result is NOT trimmed, and it is the same length as the input.
+func EMAContinue(inputData []float64, windowWidth int, seed float64) ([]float64, error) {
+	if len(inputData) == 0 {
+		return nil, errors.New("stat4trading::EMAContinue: input data set cannot be empty")
 	}
 
-	result := make([]string, len(referenceGraph))
-	result[0] = ""
-
-	for i := 1; i < len(referenceGraph); i++ {
-		if referenceGraph[i-1] > investigatedGraph[i-1] && referenceGraph[i] < investigatedGraph[i] {
-			result[i] = "BOTTOM-TO-TOP"
-		} else if referenceGraph[i-1] < investigatedGraph[i-1] && referenceGraph[i] > investigatedGraph[i] {
-			result[i] = "TOP-TO-BOTTOM"
-		} else {
-			result[i] = ""
-		}
+	if windowWidth < 1 {
+		return nil, errors.New("stat4trading::EMAContinue: window width should be positive")
 	}
 
-	return result, nil
-}
+	alpha := float64(2) / float64(1+windowWidth)
 
-// FindIntersectionPointOfTwoSegments - tries to solve a system of two linear equations and returns 3 parameters:
-//  1. Coordinates of intersection point if they are exist
-//  2. Boolean indicating if solution exists, or it does not
-//     for example, solution does not exist if:
-//     a) two lines are parallel,
-//     b) if intersection point exists, but it is outside of the common projection
-//  3. Error in other abnormal situation.
-//
-// PLEASE NOTE: This function searches intersection of SEGMENTS, NOT LINES!!!
-func FindIntersectionPointOfTwoSegments(lineA LineDefinedByTwoPoints, lineB LineDefinedByTwoPoints) (PointCoordinates, bool, error) {
-	// lineA: y = kx+b
-	// lineB: y = mx+c
+	return calculateEMAFromSeed(inputData, alpha, seed), nil
+}
 
-	deltaXA := lineA.PointB.X - lineA.PointA.X
-	deltaXB := lineB.PointB.X - lineB.PointA.X
+// EMAState calculates EMA incrementally, value by value (e.g. over a live tick stream), without recalculating the whole series.
+// The first pushed value seeds EMA, the same as in EMAWithAlpha, so pushing inputData one by one gives the same values as EMAWithAlpha.
+// Use NewEMAState or NewEMAStateAlpha to create it. EMAState is NOT safe for concurrent use.
+type EMAState struct {
+	alpha    float64
+	value    float64
+	isSeeded bool
+}
 
-	if deltaXA <= 1e-9 || deltaXB <= 1e-9 {
-		return PointCoordinates{}, false, errors.New("stat4trading::FindIntersectionPointOfTwoLines error: deltaX = x2-x1 = 0, while it should not be so. There is an error in input data")
+// NewEMAState creates EMAState with alpha = 2/(1+windowWidth), the same as in EMA.
+func NewEMAState(windowWidth int) (*EMAState, error) {
+	if windowWidth < 1 {
+		return nil, errors.New("stat4trading::NewEMAState: window width should be positive")
 	}
 
-	k := (lineA.PointB.Y - lineA.PointA.Y) / deltaXA
-	b := lineA.PointA.Y - k*lineA.PointA.X
-
-	m := (lineB.PointB.Y - lineB.PointA.Y) / deltaXB
-	c := lineB.PointA.Y - m*lineB.PointA.X
+	return &EMAState{alpha: float64(2) / float64(1+windowWidth)}, nil
+}
 
-	if math.Abs(k-m) <= 1e-9 {
-		// Solution DOES NOT exist, but the situation IS REGULAR!
-		return PointCoordinates{}, false, nil
+// NewEMAStateAlpha creates EMAState with explicitly specified smoothing factor alpha (0 < alpha <= 1).
+func NewEMAStateAlpha(alpha float64) (*EMAState, error) {
+	if alpha <= 0 || alpha > 1 {
+		return nil, errors.New("stat4trading::NewEMAStateAlpha: alpha should be in range (0, 1]")
 	}
 
-	x := (c - b) / (k - m)
+	return &EMAState{alpha: alpha}, nil
+}
 
-	y1 := k*x + b
-	y2 := m*x + c
+// Push adds the next value to EMA and returns updated EMA: ema = alpha*value + (1-alpha)*ema.
+func (s *EMAState) Push(value float64) float64 {
+	if !s.isSeeded {
+		s.value = value
+		s.isSeeded = true
 
-	// This case should never happen, and here just for self-control:
-	if y1-y2 > 1e-9 {
-		fmt.Printf("Y1 = %.10f\nY2 = %.10f\n", y1, y2)
-		panic("Self-control failed: Error in linear equation solving logic!")
+		return s.value
 	}
 
-	// We found that LINES are intersect, now let's check if SEGMENTS are intersect!
-	commonProjectionStartX, _, _ := FindMax([]float64{lineA.PointA.X, lineB.PointA.X})
-	commonProjectionEndX, _, _ := FindMin([]float64{lineA.PointB.X, lineB.PointB.X})
+	s.value = s.alpha*value + (1-s.alpha)*s.value
 
-	// Common projection is FROM commonProjectionStartX TO commonProjectionEndX, not vise versa!
-	if commonProjectionStartX <= x && x <= commonProjectionEndX {
-		return PointCoordinates{X: x, Y: y1}, true, nil
+	return s.value
+}
+
+// Value returns current EMA, or NaN if nothing has been pushed yet.
+func (s *EMAState) Value() float64 {
+	if !s.isSeeded {
+		return math.NaN()
 	}
 
-	return PointCoordinates{}, false, nil
+	return s.value
 }
 
-func FindEquationOfLineGivenByTwoPoints(lineByTwoPoints LineDefinedByTwoPoints) (LineDefinedByParameters, error) {
-	// We solve system of 2 equations:
-	// ax1 + b = y1
-	// ax2 + b = y2
-	// We should find parameters a and b, so we'll know the equation of line
-
-	// Main Determinant:
-	// x1 1
-	// x2 1
+// Reset forgets all the pushed values, so the next Push seeds EMA again. Alpha is kept.
+func (s *EMAState) Reset() {
+	s.value = 0
+	s.isSeeded = false
+}
 
-	// Determinant A:
-	// y1 1
-	// y2 1
+// AlphaFromHalfLife converts EMA half-life (number of periods after which weight of a value decays by half)
+// to smoothing factor alpha = 1 - exp(ln(0.5) / halfLife), which can be used with EMAWithAlpha.
+func AlphaFromHalfLife(halfLife float64) (float64, error) {
+	if halfLife <= 0 {
+		return 0, errors.New("stat4trading::AlphaFromHalfLife: half-life should be positive")
+	}
 
-	// Determinant B:
-	// x1 y1
-	// x2 y2
+	return 1 - math.Exp(math.Log(0.5)/halfLife), nil
+}
 
-	// a = DetA/MainDet
-	// b = DetB/MainDet
+// ZLEMA - ZeroLagExponentialMovingAverage
+// EMA is applied not to the input data itself, but to the de-lagged series: data[i] + (data[i] - data[i-lag]),
+// where lag = (period-1)/2. This compensates the inherent lag of EMA.
+// The first lag elements are consumed by de-lagging, so output data length is len(inputData) - lag - period + 1.
+func ZLEMA(inputData []float64, period int) ([]float64, error) {
+	if period < 1 {
+		return nil, errors.New("stat4trading::ZLEMA: period should be positive")
+	}
 
-	detMain := lineByTwoPoints.PointA.X - lineByTwoPoints.PointB.X
+	lag := (period - 1) / 2
+	deLaggedDataLength := len(inputData) - lag
+	outputDataLength := CalculateOutputDataLengthAfterMA(deLaggedDataLength, period)
 
-	if isAlmostEqual(detMain, 0.0) {
-		return LineDefinedByParameters{}, errors.New("x1 and x2 are the same. Unable to unambiguously define a line")
+	if outputDataLength <= 0 {
+		return nil, fmt.Errorf("stat4trading::ZLEMA: not enough data to calculate ZLEMA of period %d, at least %d points are required (%d for lag offset plus %d for EMA)", period, lag+period, lag, period)
 	}
 
-	detA := lineByTwoPoints.PointA.Y - lineByTwoPoints.PointB.Y
-	detB := lineByTwoPoints.PointA.X*lineByTwoPoints.PointB.Y - lineByTwoPoints.PointA.Y*lineByTwoPoints.PointB.X
+	deLaggedData := make([]float64, deLaggedDataLength)
 
-	a := detA / detMain
-	b := detB / detMain
+	for i := lag; i < len(inputData); i++ {
+		deLaggedData[i-lag] = inputData[i] + (inputData[i] - inputData[i-lag])
+	}
 
-	return LineDefinedByParameters{ParamA: a, ParamB: b}, nil
+	return EMA(deLaggedData, period, outputDataLength)
 }
 
-func SmoothBy3Points(inData []float64, passesNum int) []float64 {
-	if passesNum <= 0 || len(inData) < 3 {
-		return inData
+// MultiSMA calculates SMA for several window widths at once (e.g. for MA ribbon: 5, 10, 20, 50, 100, 200).
+// Cumulative sums of the input are calculated once, so every window average takes O(1) regardless of window width.
+// The result maps window width to SMA of that width, output data lengths are the same as after SMA.
+func MultiSMA(inputData []float64, windows []int) (map[int][]float64, error) {
+	if len(windows) == 0 {
+		return nil, errors.New("stat4trading::MultiSMA: at least one window width is required")
 	}
 
-	startIterationIndex := 1
-	endIterationIndex := len(inData) - 2
-	lastIndex := len(inData) - 1
-
-	smoothedData := make([]float64, len(inData))
-
-	for p := 0; p < passesNum; p++ {
-		for i := startIterationIndex; i <= endIterationIndex; i++ {
-			smoothedData[i] = (inData[i-1] + inData[i] + inData[i+1]) / 3
+	for _, windowWidth := range windows {
+		if windowWidth <= 0 || windowWidth > len(inputData) {
+			return nil, fmt.Errorf("stat4trading::MultiSMA: window width %d should be positive and not greater than data length %d", windowWidth, len(inputData))
 		}
-
-		smoothedData[0] = (5*inData[0] + 2*inData[1] - inData[2]) / 6
-		smoothedData[lastIndex] = (-inData[lastIndex-2] + 2*inData[lastIndex-1] + 5*inData[lastIndex]) / 6
-
-		copy(inData, smoothedData)
 	}
 
-	return smoothedData
-}
+	// cumulativeSums[i] is the sum of the first i values, so sum of inputData[a:b] = cumulativeSums[b] - cumulativeSums[a].
+	cumulativeSums := make([]float64, len(inputData)+1)
 
-func SmoothBy5Points(inData []float64, passesNum int) []float64 {
-	if passesNum <= 0 || len(inData) < 5 {
-		return inData
+	for i := 0; i < len(inputData); i++ {
+		cumulativeSums[i+1] = cumulativeSums[i] + inputData[i]
 	}
 
-	startIterationIndex := 2
-	endIterationIndex := len(inData) - 3
-	lastIndex := len(inData) - 1
+	result := make(map[int][]float64, len(windows))
 
-	smoothedData := make([]float64, len(inData))
+	for _, windowWidth := range windows {
+		processedData := make([]float64, CalculateOutputDataLengthAfterMA(len(inputData), windowWidth))
 
-	for p := 0; p < passesNum; p++ {
-		for i := startIterationIndex; i <= endIterationIndex; i++ {
-			smoothedData[i] = (inData[i-2] + inData[i-1] + inData[i] + inData[i+1] + inData[i+2]) / 5
+		for i := 0; i < len(processedData); i++ {
+			processedData[i] = (cumulativeSums[i+windowWidth] - cumulativeSums[i]) / float64(windowWidth)
 		}
 
-		smoothedData[0] = (3*inData[0] + 2*inData[1] + inData[2] - inData[4]) / 5
-		smoothedData[1] = (4*inData[0] + 3*inData[1] + 2*inData[2] + inData[3]) / 10
-		smoothedData[lastIndex-1] = (inData[lastIndex-3] + 2*inData[lastIndex-2] + 3*inData[lastIndex-1] + 4*inData[lastIndex]) / 10
-		smoothedData[lastIndex] = (-inData[lastIndex-4] + inData[lastIndex-2] + 2*inData[lastIndex-1] + 3*inData[lastIndex]) / 5
-
-		copy(inData, smoothedData)
+		result[windowWidth] = processedData
 	}
 
-	return smoothedData
+	return result, nil
 }
 
-// SmoothAdaptive performs adaptive smoothing:
-// 5-point smoothing in priority,
-// but if there are not enough points for 5-point smoothing, it does 3-point smoothing,
-// and if there are not enough points even for 3-p smoothing, it just returns the input
-func SmoothAdaptive(inData []float64, passesNum int) []float64 {
-	if len(inData) < 3 {
-		return inData
-	}
+// MAType defines type of moving average calculated by ComputeMA.
+type MAType int
 
-	if len(inData) < 5 {
-		return SmoothBy3Points(inData, passesNum)
+const (
+	MATypeSMA MAType = iota
+	MATypeWMA
+	MATypeEMA
+	MATypeZLEMA
+)
+
+func (t MAType) String() string {
+	switch t {
+	case MATypeSMA:
+		return "SMA"
+	case MATypeWMA:
+		return "WMA"
+	case MATypeEMA:
+		return "EMA"
+	case MATypeZLEMA:
+		return "ZLEMA"
 	}
 
-	return SmoothBy5Points(inData, passesNum)
+	return fmt.Sprintf("MAType(%d)", int(t))
 }
 
-func FindMax[N Numeric](data []N) (N, int, error) {
-	if len(data) == 0 {
-		return 0, 0, errors.New("stat4trading::FindMax: Input data set cannot be empty!")
+// ComputeMA calculates moving average of the given type, calculating expected output data length internally.
+// Output data length depends on the type: it is the same as after SMA for SMA, WMA and EMA,
+// and it is shorter by (windowWidth-1)/2 for ZLEMA (see ZLEMA).
+func ComputeMA(inputData []float64, windowWidth int, maType MAType) ([]float64, error) {
+	if windowWidth < 1 {
+		return nil, errors.New("stat4trading::ComputeMA: window width should be positive")
 	}
 
-	maxValue := data[0]
-	index := 0
+	expectedOutputDataLength := CalculateOutputDataLengthAfterMA(len(inputData), windowWidth)
 
-	for i := 1; i < len(data); i++ {
-		if data[i] > maxValue {
-			maxValue = data[i]
-			index = i
-		}
+	switch maType {
+	case MATypeSMA:
+		return SMA(inputData, windowWidth, expectedOutputDataLength)
+	case MATypeWMA:
+		return WMA(inputData, windowWidth, expectedOutputDataLength)
+	case MATypeEMA:
+		return EMA(inputData, windowWidth, expectedOutputDataLength)
+	case MATypeZLEMA:
+		return ZLEMA(inputData, windowWidth)
 	}
 
-	return maxValue, index, nil
+	return nil, fmt.Errorf("stat4trading::ComputeMA: unknown moving average type %v", maType)
 }
 
-func FindMin[N Numeric](data []N) (N, int, error) {
-	if len(data) == 0 {
-		return 0, 0, errors.New("stat4trading::FindMin: Input data set cannot be empty!")
+// OscillatorFromMAs calculates difference between fast and slow moving averages of the given type (see ComputeMA),
+// i.e. generalized MACD line (MACD is OscillatorFromMAs with EMA, fastWindow = 12 and slowWindow = 26).
+// Moving averages are aligned by their most recent values (see SubtractAligned), so output data length is the length of slow MA.
+func OscillatorFromMAs(data []float64, fastWindow, slowWindow int, maType MAType) ([]float64, error) {
+	if fastWindow >= slowWindow {
+		return nil, errors.New("stat4trading::OscillatorFromMAs: fast window should be less than slow window")
 	}
 
-	minValue := data[0]
-	index := 0
+	fastMA, err := ComputeMA(data, fastWindow, maType)
 
-	for i := 1; i < len(data); i++ {
-		if data[i] < minValue {
-			minValue = data[i]
-			index = i
-		}
+	if err != nil {
+		return nil, fmt.Errorf("stat4trading::OscillatorFromMAs: %w", err)
 	}
 
-	return minValue, index, nil
-}
+	slowMA, err := ComputeMA(data, slowWindow, maType)
 
-func IsDataSortedASC[N Numeric](data []N) bool {
-	for i := 1; i < len(data); i++ {
-		if data[i] <= data[i-1] {
-			return false
-		}
+	if err != nil {
+		return nil, fmt.Errorf("stat4trading::OscillatorFromMAs: %w", err)
 	}
 
-	return true
-}
+	result, err := SubtractAligned(fastMA, slowMA)
 
-func IsDataSortedDESC[N Numeric](data []N) bool {
-	for i := 1; i < len(data); i++ {
-		if data[i] >= data[i-1] {
-			return false
-		}
+	if err != nil {
+		return nil, fmt.Errorf("stat4trading::OscillatorFromMAs: %w", err)
 	}
 
-	return true
+	return result, nil
 }
 
-func isAlmostEqual(v1 float64, v2 float64) bool {
-	const float64EqualityThreshold = 1e-9
+// ComputeMABatch calculates moving average of the given type (see ComputeMA) for every series in seriesBySymbol in parallel,
+// using a bounded pool of runtime.NumCPU() goroutines. Input data sets are not modified.
+// Error of one series doesn't stop calculation of the others: successful results are returned in the first map,
+// and errors are returned in the second map, both keyed by symbol (every symbol is present in exactly one of them).
+func ComputeMABatch(seriesBySymbol map[string][]float64, windowWidth int, maType MAType) (map[string][]float64, map[string]error) {
+	results := make(map[string][]float64, len(seriesBySymbol))
+	errs := make(map[string]error)
 
-	if math.Abs(v1-v2) <= float64EqualityThreshold {
-		return true
+	workersNum := runtime.NumCPU()
+
+	if workersNum > len(seriesBySymbol) {
+		workersNum = len(seriesBySymbol)
+	}
+
+	symbols := make(chan string)
+	mutex := sync.Mutex{}
+	waitGroup := sync.WaitGroup{}
+
+	for i := 0; i < workersNum; i++ {
+		waitGroup.Add(1)
+
+		go func() {
+			defer waitGroup.Done()
+
+			for symbol := range symbols {
+				movingAverage, err := ComputeMA(seriesBySymbol[symbol], windowWidth, maType)
+
+				mutex.Lock()
+
+				if err != nil {
+					errs[symbol] = fmt.Errorf("stat4trading::ComputeMABatch: symbol %q: %w", symbol, err)
+				} else {
+					results[symbol] = movingAverage
+				}
+
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	for symbol := range seriesBySymbol {
+		symbols <- symbol
+	}
+
+	close(symbols)
+	waitGroup.Wait()
+
+	return results, errs
+}
+
+// RollingMax calculates maximum of every window of width windowWidth (highest high for Stochastic, Donchian channels, etc).
+// Windowing and output data length are the same as in SMA.
+// expectedOutputDataLength is the required parameter for self-control.
+// It should be known BEFORE doing calculation, and if it is calculated incorrectly you can't handle obtained result in a right way.
+func RollingMax(data []float64, windowWidth, expectedOutputDataLength int) ([]float64, error) {
+	outputDataLength := CalculateOutputDataLengthAfterMA(len(data), windowWidth)
+
+	if windowWidth < 1 || outputDataLength <= 0 {
+		return nil, errors.New("stat4trading::RollingMax: not enough data to calculate rolling maximum of specified window width, increase data set or reduce window width")
+	}
+
+	if expectedOutputDataLength != outputDataLength {
+		return nil, errors.New("stat4trading::RollingMax: incorrectly calculated expected output data length")
+	}
+
+	return calculateRollingExtremum(data, windowWidth, func(a, b float64) bool { return a >= b }), nil
+}
+
+// RollingMin calculates minimum of every window of width windowWidth (lowest low for Stochastic, Donchian channels, etc).
+// Windowing and output data length are the same as in SMA.
+// expectedOutputDataLength is the required parameter for self-control.
+// It should be known BEFORE doing calculation, and if it is calculated incorrectly you can't handle obtained result in a right way.
+func RollingMin(data []float64, windowWidth, expectedOutputDataLength int) ([]float64, error) {
+	outputDataLength := CalculateOutputDataLengthAfterMA(len(data), windowWidth)
+
+	if windowWidth < 1 || outputDataLength <= 0 {
+		return nil, errors.New("stat4trading::RollingMin: not enough data to calculate rolling minimum of specified window width, increase data set or reduce window width")
+	}
+
+	if expectedOutputDataLength != outputDataLength {
+		return nil, errors.New("stat4trading::RollingMin: incorrectly calculated expected output data length")
+	}
+
+	return calculateRollingExtremum(data, windowWidth, func(a, b float64) bool { return a <= b }), nil
+}
+
+// calculateRollingExtremum uses monotonic deque of indexes, so it works in O(n) regardless of window width.
+// isBetterOrEqual(a, b) should return true if a is better (or equal) candidate for the extremum than b.
+func calculateRollingExtremum(data []float64, windowWidth int, isBetterOrEqual func(a, b float64) bool) []float64 {
+	result := make([]float64, CalculateOutputDataLengthAfterMA(len(data), windowWidth))
+
+	// Indexes of candidates for extremum. Values at these indexes are monotonic: deque[0] is the extremum of current window.
+	deque := make([]int, 0, windowWidth)
+
+	for i := 0; i < len(data); i++ {
+		// Drop candidates which are outside the window.
+		if len(deque) > 0 && deque[0] <= i-windowWidth {
+			deque = deque[1:]
+		}
+
+		// Drop candidates which can never become the extremum, because the new value is better and newer.
+		for len(deque) > 0 && isBetterOrEqual(data[i], data[deque[len(deque)-1]]) {
+			deque = deque[:len(deque)-1]
+		}
+
+		deque = append(deque, i)
+
+		if i >= windowWidth-1 {
+			result[i-windowWidth+1] = data[deque[0]]
+		}
+	}
+
+	return result
+}
+
+// RollingSlope fits a least squares line to every window of width windowWidth (using x = 0..windowWidth-1)
+// and returns only its slope, which shows steepness of the trend over time (Linear Regression Slope indicator).
+// Windowing and output data length are the same as in SMA.
+// expectedOutputDataLength is the required parameter for self-control.
+// It should be known BEFORE doing calculation, and if it is calculated incorrectly you can't handle obtained result in a right way.
+func RollingSlope(data []float64, windowWidth, expectedOutputDataLength int) ([]float64, error) {
+	if windowWidth < 2 {
+		return nil, errors.New("stat4trading::RollingSlope: window width should be at least 2 to fit a line")
+	}
+
+	outputDataLength := CalculateOutputDataLengthAfterMA(len(data), windowWidth)
+
+	if outputDataLength <= 0 {
+		return nil, errors.New("stat4trading::RollingSlope: not enough data to calculate slope of specified window width, increase data set or reduce window width")
+	}
+
+	if expectedOutputDataLength != outputDataLength {
+		return nil, errors.New("stat4trading::RollingSlope: incorrectly calculated expected output data length")
+	}
+
+	// x values are the same for every window, so their mean and sum of squared deviations are calculated once.
+	meanX := float64(windowWidth-1) / 2
+	sxx := float64(windowWidth*(windowWidth*windowWidth-1)) / 12
+
+	processedData := make([]float64, outputDataLength)
+
+	for i := 0; i < outputDataLength; i++ {
+		sxy := 0.0
+
+		for j := 0; j < windowWidth; j++ {
+			// sum((x - meanX) * meanY) = 0, so there is no need to subtract meanY
+			sxy += (float64(j) - meanX) * data[i+j]
+		}
+
+		processedData[i] = sxy / sxx
+	}
+
+	return processedData, nil
+}
+
+// MarketRegime is the type of market behaviour detected by DetectRegime.
+type MarketRegime string
+
+const (
+	TrendingRegime MarketRegime = "TRENDING"
+	RangingRegime  MarketRegime = "RANGING"
+)
+
+// DetectRegime labels every window of width windowWidth as trending or ranging by the R-squared (coefficient of determination)
+// of a least squares line fitted to the window (using x = 0..windowWidth-1):
+// window is TRENDING if R-squared >= rSquaredThreshold, and RANGING otherwise (0.5..0.7 is a reasonable threshold to start with).
+// R-squared shows how much of the price variation inside the window is explained by a straight line,
+// so it doesn't depend on the direction of the trend - use RollingSlope to know it. Window with all equal values is RANGING.
+// Windowing and output data length are the same as in SMA (i.e. every label corresponds to the last point of the window).
+func DetectRegime(data []float64, windowWidth int, rSquaredThreshold float64) ([]MarketRegime, error) {
+	if windowWidth < 3 {
+		return nil, errors.New("stat4trading::DetectRegime: window width should be at least 3 to make R-squared meaningful")
+	}
+
+	if rSquaredThreshold < 0 || rSquaredThreshold > 1 {
+		return nil, errors.New("stat4trading::DetectRegime: R-squared threshold should be in range [0, 1]")
+	}
+
+	outputDataLength := CalculateOutputDataLengthAfterMA(len(data), windowWidth)
+
+	if outputDataLength <= 0 {
+		return nil, errors.New("stat4trading::DetectRegime: not enough data to detect regime of specified window width, increase data set or reduce window width")
+	}
+
+	meanX := float64(windowWidth-1) / 2
+	sxx := float64(windowWidth*(windowWidth*windowWidth-1)) / 12
+
+	regimes := make([]MarketRegime, outputDataLength)
+
+	for i := 0; i < outputDataLength; i++ {
+		window := data[i : i+windowWidth]
+		meanY := mean(window)
+		sxy := 0.0
+		syy := 0.0
+
+		for j := 0; j < windowWidth; j++ {
+			sxy += (float64(j) - meanX) * (window[j] - meanY)
+			syy += (window[j] - meanY) * (window[j] - meanY)
+		}
+
+		regimes[i] = RangingRegime
+
+		if isAlmostEqual(syy, 0.0) {
+			continue
+		}
+
+		// For simple linear regression R-squared is the squared correlation between x and y.
+		if rSquared := sxy * sxy / (sxx * syy); rSquared >= rSquaredThreshold {
+			regimes[i] = TrendingRegime
+		}
+	}
+
+	return regimes, nil
+}
+
+// ForEachWindow calls fn for every window of width windowWidth sliding over data one step at a time,
+// passing index of the first element of the window in data, and the window itself.
+// The number of calls is the same as output data length after SMA.
+// PLEASE NOTE: window is a subslice of data (not a copy, in contrast to RollingApply), so fn should not modify it.
+func ForEachWindow[N Numeric](data []N, windowWidth int, fn func(start int, window []N)) error {
+	if fn == nil {
+		return errors.New("stat4trading::ForEachWindow: fn cannot be nil")
+	}
+
+	if windowWidth <= 0 || windowWidth > len(data) {
+		return errors.New("stat4trading::ForEachWindow: window width should be in range [1, len(data)]")
+	}
+
+	for start := 0; start+windowWidth <= len(data); start++ {
+		fn(start, data[start:start+windowWidth])
+	}
+
+	return nil
+}
+
+// RollingApply calls fn for every window of width windowWidth and collects the results, so any rolling statistic can be calculated.
+// fn receives a COPY of the window, so it cannot accidentally modify input data set.
+// The copy is reused between calls, so fn should not retain it after return.
+// Windowing and output data length are the same as in SMA.
+// expectedOutputDataLength is the required parameter for self-control.
+// It should be known BEFORE doing calculation, and if it is calculated incorrectly you can't handle obtained result in a right way.
+func RollingApply(data []float64, windowWidth, expectedOutputDataLength int, fn func(window []float64) float64) ([]float64, error) {
+	if fn == nil {
+		return nil, errors.New("stat4trading::RollingApply: fn cannot be nil")
+	}
+
+	outputDataLength := CalculateOutputDataLengthAfterMA(len(data), windowWidth)
+
+	if windowWidth < 1 || outputDataLength <= 0 {
+		return nil, errors.New("stat4trading::RollingApply: not enough data to apply function to specified window width, increase data set or reduce window width")
+	}
+
+	if expectedOutputDataLength != outputDataLength {
+		return nil, errors.New("stat4trading::RollingApply: incorrectly calculated expected output data length")
+	}
+
+	processedData := make([]float64, outputDataLength)
+	window := make([]float64, windowWidth)
+
+	for i := 0; i < outputDataLength; i++ {
+		copy(window, data[i:i+windowWidth])
+		processedData[i] = fn(window)
+	}
+
+	return processedData, nil
+}
+
+// PercentileFilter calculates the given percentile (0..100) of every window of width windowWidth
+// (percentile = 50 gives the median filter). Percentiles are calculated with linear interpolation between the closest ranks.
+// Windowing and output data length are the same as in SMA.
+// expectedOutputDataLength is the required parameter for self-control.
+// It should be known BEFORE doing smoothing, and if it is calculated incorrectly you can't handle obtained result in a right way.
+func PercentileFilter(inputData []float64, windowWidth int, percentile float64, expectedOutputDataLength int) ([]float64, error) {
+	if percentile < 0 || percentile > 100 {
+		return nil, errors.New("stat4trading::PercentileFilter: percentile should be in range [0, 100]")
+	}
+
+	result, err := RollingApply(inputData, windowWidth, expectedOutputDataLength, func(window []float64) float64 {
+		// RollingApply passes a copy of the window, so it can be sorted in place.
+		sort.Float64s(window)
+
+		return percentileOfSorted(window, percentile)
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("stat4trading::PercentileFilter: %w", err)
+	}
+
+	return result, nil
+}
+
+// RollingPercentRank calculates percent rank (0..100) of the latest value of every window of width windowWidth:
+// the share of the previous windowWidth-1 values of the window which are strictly less than the latest value.
+// 100 means the latest value is the highest in the window, 0 means no previous value is lower.
+// Windowing and output data length are the same as in SMA.
+// expectedOutputDataLength is the required parameter for self-control.
+// It should be known BEFORE doing calculation, and if it is calculated incorrectly you can't handle obtained result in a right way.
+func RollingPercentRank(data []float64, windowWidth, expectedOutputDataLength int) ([]float64, error) {
+	if windowWidth < 2 {
+		return nil, errors.New("stat4trading::RollingPercentRank: window width should be at least 2")
+	}
+
+	result, err := RollingApply(data, windowWidth, expectedOutputDataLength, func(window []float64) float64 {
+		latest := window[len(window)-1]
+		lowerCount := 0
+
+		for j := 0; j < len(window)-1; j++ {
+			if window[j] < latest {
+				lowerCount++
+			}
+		}
+
+		return 100 * float64(lowerCount) / float64(len(window)-1)
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("stat4trading::RollingPercentRank: %w", err)
+	}
+
+	return result, nil
+}
+
+// RollingZScore calculates z-score of the latest value of every window of width windowWidth:
+// (latest - mean(window)) / stdDev(window), where stdDev is population standard deviation.
+// It shows how many standard deviations the latest value is away from the window average (mean reversion strategies usually enter when |z| > 2).
+// Windowing and output data length are the same as in SMA.
+// expectedOutputDataLength is the required parameter for self-control.
+// It should be known BEFORE doing calculation, and if it is calculated incorrectly you can't handle obtained result in a right way.
+func RollingZScore(data []float64, windowWidth, expectedOutputDataLength int) ([]float64, error) {
+	if windowWidth < 2 {
+		return nil, errors.New("stat4trading::RollingZScore: window width should be at least 2")
+	}
+
+	outputDataLength := CalculateOutputDataLengthAfterMA(len(data), windowWidth)
+
+	if outputDataLength <= 0 {
+		return nil, errors.New("stat4trading::RollingZScore: not enough data to calculate z-score of specified window width, increase data set or reduce window width")
+	}
+
+	if expectedOutputDataLength != outputDataLength {
+		return nil, errors.New("stat4trading::RollingZScore: incorrectly calculated expected output data length")
+	}
+
+	processedData := make([]float64, outputDataLength)
+
+	for i := 0; i < outputDataLength; i++ {
+		window := data[i : i+windowWidth]
+		stdDev := standardDeviation(window)
+
+		if isAlmostEqual(stdDev, 0.0) {
+			return nil, fmt.Errorf("stat4trading::RollingZScore: standard deviation of the window ending at index %d is zero, z-score is undefined", i+windowWidth-1)
+		}
+
+		processedData[i] = (window[windowWidth-1] - mean(window)) / stdDev
+	}
+
+	return processedData, nil
+}
+
+// RollingSkewness calculates (population) skewness of every window of width windowWidth: m3 / m2^1.5,
+// where mK is the K-th central moment of the window.
+// Windowing and output data length are the same as in SMA.
+// expectedOutputDataLength is the required parameter for self-control.
+// It should be known BEFORE doing calculation, and if it is calculated incorrectly you can't handle obtained result in a right way.
+func RollingSkewness(data []float64, windowWidth, expectedOutputDataLength int) ([]float64, error) {
+	result, err := calculateRollingMomentRatio(data, windowWidth, expectedOutputDataLength, func(m2, m3, m4 float64) float64 {
+		return m3 / math.Pow(m2, 1.5)
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("stat4trading::RollingSkewness: %w", err)
+	}
+
+	return result, nil
+}
+
+// RollingKurtosis calculates (population) excess kurtosis of every window of width windowWidth: m4 / m2^2 - 3,
+// where mK is the K-th central moment of the window, so normal distribution has kurtosis 0.
+// Windowing and output data length are the same as in SMA.
+// expectedOutputDataLength is the required parameter for self-control.
+// It should be known BEFORE doing calculation, and if it is calculated incorrectly you can't handle obtained result in a right way.
+func RollingKurtosis(data []float64, windowWidth, expectedOutputDataLength int) ([]float64, error) {
+	result, err := calculateRollingMomentRatio(data, windowWidth, expectedOutputDataLength, func(m2, m3, m4 float64) float64 {
+		return m4/(m2*m2) - 3
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("stat4trading::RollingKurtosis: %w", err)
+	}
+
+	return result, nil
+}
+
+// calculateRollingMomentRatio calculates central moments m2, m3, m4 of every window and passes them to ratio.
+// Windows with zero variance are reported as an error, because both skewness and kurtosis are undefined for them.
+func calculateRollingMomentRatio(data []float64, windowWidth, expectedOutputDataLength int, ratio func(m2, m3, m4 float64) float64) ([]float64, error) {
+	outputDataLength := CalculateOutputDataLengthAfterMA(len(data), windowWidth)
+
+	if windowWidth < 2 || outputDataLength <= 0 {
+		return nil, errors.New("not enough data to calculate moments of specified window width (which should be at least 2), increase data set or reduce window width")
+	}
+
+	if expectedOutputDataLength != outputDataLength {
+		return nil, errors.New("incorrectly calculated expected output data length")
+	}
+
+	processedData := make([]float64, outputDataLength)
+
+	for i := 0; i < outputDataLength; i++ {
+		window := data[i : i+windowWidth]
+		windowMean := mean(window)
+		m2, m3, m4 := 0.0, 0.0, 0.0
+
+		for j := 0; j < len(window); j++ {
+			deviation := window[j] - windowMean
+			m2 += deviation * deviation
+			m3 += deviation * deviation * deviation
+			m4 += deviation * deviation * deviation * deviation
+		}
+
+		m2 /= float64(windowWidth)
+		m3 /= float64(windowWidth)
+		m4 /= float64(windowWidth)
+
+		if isAlmostEqual(m2, 0.0) {
+			return nil, fmt.Errorf("variance is zero in window starting at index %d", i)
+		}
+
+		processedData[i] = ratio(m2, m3, m4)
+	}
+
+	return processedData, nil
+}
+
+// BollingerPercentB calculates %B = (price - lower) / (upper - lower), which shows where price is relatively to Bollinger Bands:
+// 0 is at the lower band, 1 is at the upper band, values outside [0, 1] are outside the bands.
+// Bollinger Bands are SMA(period) ± numStdDev population standard deviations of the same window.
+// If the window is flat (upper band = lower band), price is exactly at the middle band, so 0.5 is returned.
+// Output data length is the same as after SMA: len(inputData) - period + 1.
+func BollingerPercentB(inputData []float64, period int, numStdDev float64) ([]float64, error) {
+	upper, _, lower, err := calculateBollingerBands(inputData, period, numStdDev)
+
+	if err != nil {
+		return nil, fmt.Errorf("stat4trading::BollingerPercentB: %w", err)
+	}
+
+	// Price which corresponds to every window is the last price of the window.
+	prices := inputData[period-1:]
+	result := make([]float64, len(upper))
+
+	for i := 0; i < len(upper); i++ {
+		bandwidth := upper[i] - lower[i]
+
+		if isAlmostEqual(bandwidth, 0.0) {
+			result[i] = 0.5
+			continue
+		}
+
+		result[i] = (prices[i] - lower[i]) / bandwidth
+	}
+
+	return result, nil
+}
+
+// BollingerBandwidth calculates (upper - lower) / middle of Bollinger Bands, which is used to detect volatility squeezes.
+// Bollinger Bands are SMA(period) ± numStdDev population standard deviations of the same window.
+// Output data length is the same as after SMA: len(inputData) - period + 1.
+func BollingerBandwidth(inputData []float64, period int, numStdDev float64) ([]float64, error) {
+	upper, middle, lower, err := calculateBollingerBands(inputData, period, numStdDev)
+
+	if err != nil {
+		return nil, fmt.Errorf("stat4trading::BollingerBandwidth: %w", err)
+	}
+
+	result := make([]float64, len(middle))
+
+	for i := 0; i < len(middle); i++ {
+		if isAlmostEqual(middle[i], 0.0) {
+			return nil, fmt.Errorf("stat4trading::BollingerBandwidth: middle band is zero at index %d, unable to calculate bandwidth", i)
+		}
+
+		result[i] = (upper[i] - lower[i]) / middle[i]
+	}
+
+	return result, nil
+}
+
+// calculateBollingerBands calculates Bollinger Bands: middle = SMA(period), upper/lower = middle ± numStdDev * standard deviation.
+func calculateBollingerBands(inputData []float64, period int, numStdDev float64) (upper, middle, lower []float64, err error) {
+	if period < 1 {
+		return nil, nil, nil, errors.New("period should be positive")
+	}
+
+	if numStdDev <= 0 {
+		return nil, nil, nil, errors.New("number of standard deviations should be positive")
+	}
+
+	middle, err = SMA(inputData, period, CalculateOutputDataLengthAfterMA(len(inputData), period))
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	stdDev := calculateRollingStdDev(inputData, period)
+	upper = make([]float64, len(middle))
+	lower = make([]float64, len(middle))
+
+	for i := 0; i < len(middle); i++ {
+		upper[i] = middle[i] + numStdDev*stdDev[i]
+		lower[i] = middle[i] - numStdDev*stdDev[i]
+	}
+
+	return upper, middle, lower, nil
+}
+
+func Subtract(initialData []float64, deductibleData []float64) ([]float64, error) {
+	if len(initialData) != len(deductibleData) {
+		return nil, errors.New("stat4trading::Subtract: both input data sets should be the same length")
+	}
+
+	result := make([]float64, len(initialData))
+
+	for i := 0; i < len(initialData); i++ {
+		result[i] = initialData[i] - deductibleData[i]
+	}
+
+	return result, nil
+}
+
+// SubtractAligned is the same as Subtract, but it accepts data sets of different lengths:
+// both data sets are first trimmed FROM THE FRONT to the length of the shorter one (see AlignTrailing),
+// so the most recent values are aligned, and then subtracted. Output data length is the length of the shorter data set.
+// It is useful to subtract outputs of moving averages with different window widths.
+func SubtractAligned(initial, deductible []float64) ([]float64, error) {
+	initialAligned, deductibleAligned := AlignTrailing(initial, deductible)
+
+	result, err := Subtract(initialAligned, deductibleAligned)
+
+	if err != nil {
+		return nil, fmt.Errorf("stat4trading::SubtractAligned: %w", err)
+	}
+
+	return result, nil
+}
+
+// Spread calculates spread of two price series for pairs trading: a[i] - hedgeRatio * b[i].
+// Both data sets should be the same length and aligned by time.
+func Spread(a, b []float64, hedgeRatio float64) ([]float64, error) {
+	if len(a) != len(b) {
+		return nil, errors.New("stat4trading::Spread: both input data sets should be the same length")
+	}
+
+	result := make([]float64, len(a))
+
+	for i := 0; i < len(a); i++ {
+		result[i] = a[i] - hedgeRatio*b[i]
+	}
+
+	return result, nil
+}
+
+// SpreadZScore calculates rolling z-score (see RollingZScore) of the spread of two price series (see Spread),
+// which is the usual entry/exit signal of statistical arbitrage: e.g. short the spread when z > 2, long when z < -2, exit around 0.
+// Windowing and output data length are the same as in SMA.
+func SpreadZScore(a, b []float64, hedgeRatio float64, windowWidth int) ([]float64, error) {
+	spread, err := Spread(a, b, hedgeRatio)
+
+	if err != nil {
+		return nil, fmt.Errorf("stat4trading::SpreadZScore: %w", err)
+	}
+
+	result, err := RollingZScore(spread, windowWidth, CalculateOutputDataLengthAfterMA(len(spread), windowWidth))
+
+	if err != nil {
+		return nil, fmt.Errorf("stat4trading::SpreadZScore: %w", err)
+	}
+
+	return result, nil
+}
+
+// CointegrationResiduals is the first step of Engle-Granger cointegration test: it regresses a on b with least squares method
+// (a = hedgeRatio * b + intercept) and returns residuals a[i] - hedgeRatio * b[i] - intercept together with the fitted hedge ratio.
+// The pair is a candidate for pairs trading if residuals are stationary (mean reverting). Stationarity is NOT tested here, but simple check is
+// to calculate Autocorrelation of residuals: for stationary residuals it decays to zero quickly, for non-stationary ones it stays close to 1.
+// Use hedgeRatio with Spread and SpreadZScore. Both data sets should be the same length and aligned by time.
+func CointegrationResiduals(a, b []float64) (residuals []float64, hedgeRatio float64, err error) {
+	if len(a) != len(b) {
+		return nil, 0, errors.New("stat4trading::CointegrationResiduals: both input data sets should be the same length")
+	}
+
+	line, err := linearRegression(b, a)
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("stat4trading::CointegrationResiduals: %w", err)
+	}
+
+	residuals = make([]float64, len(a))
+
+	for i := 0; i < len(a); i++ {
+		residuals[i] = a[i] - EvaluateLineAt(line, b[i])
+	}
+
+	return residuals, line.ParamA, nil
+}
+
+// AlignTrailing trims both data sets from the front to the length of the shorter one,
+// so they line up on their most recent points.
+// It is useful to combine outputs of moving averages with different window widths, e.g. SMA(20) and SMA(50),
+// before passing them to Subtract or FindIntersectionDirections.
+// PLEASE NOTE: returned slices share underlying arrays with the input slices.
+func AlignTrailing(a, b []float64) (aAligned, bAligned []float64) {
+	commonLength := len(a)
+
+	if len(b) < commonLength {
+		commonLength = len(b)
+	}
+
+	return a[len(a)-commonLength:], b[len(b)-commonLength:]
+}
+
+// DownsampleMode defines how a group of consecutive samples is reduced to one value by Downsample.
+type DownsampleMode string
+
+const (
+	DownsampleLast DownsampleMode = "last"
+	DownsampleMean DownsampleMode = "mean"
+	DownsampleMax  DownsampleMode = "max"
+	DownsampleMin  DownsampleMode = "min"
+)
+
+// Downsample groups every factor consecutive samples and reduces each group to one value according to mode
+// (e.g. to convert minute data to hourly data use factor = 60).
+// Trailing partial group (if len(data) is not a multiple of factor) is DROPPED, because it is not complete yet,
+// so output data length is len(data) / factor.
+func Downsample(data []float64, factor int, mode DownsampleMode) ([]float64, error) {
+	if factor < 1 {
+		return nil, errors.New("stat4trading::Downsample: factor should be positive")
+	}
+
+	outputDataLength := len(data) / factor
+
+	if outputDataLength == 0 {
+		return nil, errors.New("stat4trading::Downsample: not enough data to compose at least one complete group, increase data set or reduce factor")
+	}
+
+	result := make([]float64, outputDataLength)
+
+	for i := 0; i < outputDataLength; i++ {
+		group := data[i*factor : (i+1)*factor]
+
+		switch mode {
+		case DownsampleLast:
+			result[i] = group[len(group)-1]
+		case DownsampleMean:
+			result[i] = mean(group)
+		case DownsampleMax:
+			result[i] = reduceGroupOrNaN(group, FindMax[float64])
+		case DownsampleMin:
+			result[i] = reduceGroupOrNaN(group, FindMin[float64])
+		default:
+			return nil, fmt.Errorf("stat4trading::Downsample: unknown downsample mode %q", mode)
+		}
+	}
+
+	return result, nil
+}
+
+// reduceGroupOrNaN applies FindMax/FindMin to the group, and returns NaN if the group consists of NaN values only.
+func reduceGroupOrNaN(group []float64, findExtremum func([]float64) (float64, int, error)) float64 {
+	value, _, err := findExtremum(group)
+
+	if err != nil {
+		return math.NaN()
+	}
+
+	return value
+}
+
+// OHLC represents a single candle (bar) of price data.
+type OHLC struct {
+	Open   float64
+	High   float64
+	Low    float64
+	Close  float64
+	Volume float64
+}
+
+// AggregateOHLC merges every factor consecutive candles into one candle of a higher timeframe
+// (e.g. to convert 1m candles to 5m candles use factor = 5):
+// Open is taken from the first candle of the group, Close from the last one, High is the group maximum,
+// Low is the group minimum and Volume is summed.
+// Same as in Downsample, trailing partial group is DROPPED, so output data length is len(candles) / factor.
+func AggregateOHLC(candles []OHLC, factor int) ([]OHLC, error) {
+	if factor < 1 {
+		return nil, errors.New("stat4trading::AggregateOHLC: factor should be positive")
+	}
+
+	outputDataLength := len(candles) / factor
+
+	if outputDataLength == 0 {
+		return nil, errors.New("stat4trading::AggregateOHLC: not enough candles to compose at least one complete group, increase data set or reduce factor")
+	}
+
+	result := make([]OHLC, outputDataLength)
+
+	for i := 0; i < outputDataLength; i++ {
+		group := candles[i*factor : (i+1)*factor]
+
+		aggregated := OHLC{
+			Open:  group[0].Open,
+			High:  group[0].High,
+			Low:   group[0].Low,
+			Close: group[len(group)-1].Close,
+		}
+
+		for j := 0; j < len(group); j++ {
+			aggregated.High = math.Max(aggregated.High, group[j].High)
+			aggregated.Low = math.Min(aggregated.Low, group[j].Low)
+			aggregated.Volume += group[j].Volume
+		}
+
+		result[i] = aggregated
+	}
+
+	return result, nil
+}
+
+// Returns calculates period-to-period returns of a price series.
+// When logReturns is false, simple returns are calculated: out[i] = prices[i+1]/prices[i] - 1,
+// when logReturns is true, logarithmic returns are calculated: out[i] = ln(prices[i+1]/prices[i]).
+// The output is one element shorter than the input.
+func Returns(prices []float64, logReturns bool) ([]float64, error) {
+	if len(prices) == 0 {
+		return nil, errors.New("stat4trading::Returns: input data set cannot be empty")
+	}
+
+	if logReturns {
+		for i := 0; i < len(prices); i++ {
+			if prices[i] <= 0 {
+				return nil, fmt.Errorf("stat4trading::Returns: log returns require positive prices, got non-positive price at index %d", i)
+			}
+		}
+	}
+
+	result := make([]float64, len(prices)-1)
+
+	for i := 1; i < len(prices); i++ {
+		if logReturns {
+			result[i-1] = math.Log(prices[i] / prices[i-1])
+			continue
+		}
+
+		if prices[i-1] == 0 {
+			return nil, fmt.Errorf("stat4trading::Returns: zero price at index %d, unable to calculate return", i-1)
+		}
+
+		result[i-1] = prices[i]/prices[i-1] - 1
+	}
+
+	return result, nil
+}
+
+// SharpeRatio calculates annualized Sharpe ratio of the series of per-period returns (e.g. daily returns from Returns):
+// mean(excess returns) / sampleStdDev(excess returns) * sqrt(periodsPerYear),
+// where riskFreeRate is ANNUAL risk-free rate, which is converted to per-period rate as riskFreeRate / periodsPerYear.
+// Use periodsPerYear = 252 for daily returns of stock market, 365 for daily returns of crypto market, etc.
+func SharpeRatio(returns []float64, riskFreeRate float64, periodsPerYear int) (float64, error) {
+	excessReturns, err := calculateExcessReturns(returns, riskFreeRate, periodsPerYear)
+
+	if err != nil {
+		return 0, fmt.Errorf("stat4trading::SharpeRatio: %w", err)
+	}
+
+	variance, err := Covariance(excessReturns, excessReturns, true)
+
+	if err != nil {
+		return 0, fmt.Errorf("stat4trading::SharpeRatio: %w", err)
+	}
+
+	if isAlmostEqual(variance, 0.0) {
+		return 0, errors.New("stat4trading::SharpeRatio: standard deviation of returns is zero")
+	}
+
+	return mean(excessReturns) / math.Sqrt(variance) * math.Sqrt(float64(periodsPerYear)), nil
+}
+
+// SortinoRatio is the same as SharpeRatio, but only downside deviation is used instead of standard deviation:
+// sqrt(mean(min(0, excess return)^2)), so upside volatility is not penalized.
+func SortinoRatio(returns []float64, riskFreeRate float64, periodsPerYear int) (float64, error) {
+	excessReturns, err := calculateExcessReturns(returns, riskFreeRate, periodsPerYear)
+
+	if err != nil {
+		return 0, fmt.Errorf("stat4trading::SortinoRatio: %w", err)
+	}
+
+	sumSquaredDownside := 0.0
+
+	for i := 0; i < len(excessReturns); i++ {
+		if excessReturns[i] < 0 {
+			sumSquaredDownside += excessReturns[i] * excessReturns[i]
+		}
+	}
+
+	downsideDeviation := math.Sqrt(sumSquaredDownside / float64(len(excessReturns)))
+
+	if isAlmostEqual(downsideDeviation, 0.0) {
+		return 0, errors.New("stat4trading::SortinoRatio: downside deviation of returns is zero")
+	}
+
+	return mean(excessReturns) / downsideDeviation * math.Sqrt(float64(periodsPerYear)), nil
+}
+
+// HistoricalVaR calculates Value at Risk from the historical distribution of returns: the loss which is not exceeded
+// with the given confidence (e.g. 0.95), i.e. the (1-confidence) percentile of returns, taken with the opposite sign.
+// Positive result means loss (e.g. 0.03 means 3% loss), percentile is calculated with linear interpolation between the closest ranks.
+func HistoricalVaR(returns []float64, confidence float64) (float64, error) {
+	if len(returns) == 0 {
+		return 0, errors.New("stat4trading::HistoricalVaR: input data set cannot be empty")
+	}
+
+	if confidence <= 0 || confidence >= 1 {
+		return 0, errors.New("stat4trading::HistoricalVaR: confidence should be in range (0, 1)")
+	}
+
+	sortedReturns := make([]float64, len(returns))
+	copy(sortedReturns, returns)
+	sort.Float64s(sortedReturns)
+
+	return -percentileOfSorted(sortedReturns, (1-confidence)*100), nil
+}
+
+// ParametricVaR is the same as HistoricalVaR, but it assumes that returns are normally distributed:
+// VaR = -(mean + z * sampleStdDev), where z is the (1-confidence) quantile of the standard normal distribution.
+func ParametricVaR(returns []float64, confidence float64) (float64, error) {
+	if len(returns) < 2 {
+		return 0, errors.New("stat4trading::ParametricVaR: at least 2 returns are required to estimate standard deviation")
+	}
+
+	if confidence <= 0 || confidence >= 1 {
+		return 0, errors.New("stat4trading::ParametricVaR: confidence should be in range (0, 1)")
+	}
+
+	variance, _ := Covariance(returns, returns, true)
+
+	// Quantile of the standard normal distribution (inverse of NormalCDF).
+	z := math.Sqrt2 * math.Erfinv(2*(1-confidence)-1)
+
+	return -(mean(returns) + z*math.Sqrt(variance)), nil
+}
+
+// calculateExcessReturns subtracts per-period risk-free rate from every return.
+func calculateExcessReturns(returns []float64, riskFreeRate float64, periodsPerYear int) ([]float64, error) {
+	if len(returns) == 0 {
+		return nil, errors.New("input data set cannot be empty")
+	}
+
+	if periodsPerYear < 1 {
+		return nil, errors.New("number of periods per year should be positive")
+	}
+
+	periodRiskFreeRate := riskFreeRate / float64(periodsPerYear)
+	result := make([]float64, len(returns))
+
+	for i := 0; i < len(returns); i++ {
+		result[i] = returns[i] - periodRiskFreeRate
+	}
+
+	return result, nil
+}
+
+// Covariance calculates covariance between two data sets of the same length.
+// If sample is true, the unbiased sample covariance (divided by N-1) is returned,
+// otherwise the population covariance (divided by N) is returned.
+func Covariance(a, b []float64, sample bool) (float64, error) {
+	if len(a) != len(b) {
+		return 0, errors.New("stat4trading::Covariance: both input data sets should be the same length")
+	}
+
+	if len(a) == 0 {
+		return 0, errors.New("stat4trading::Covariance: input data sets cannot be empty")
+	}
+
+	if sample && len(a) < 2 {
+		return 0, errors.New("stat4trading::Covariance: at least 2 points are required to calculate sample covariance")
+	}
+
+	meanA := mean(a)
+	meanB := mean(b)
+	sum := 0.0
+
+	for i := 0; i < len(a); i++ {
+		sum += (a[i] - meanA) * (b[i] - meanB)
+	}
+
+	if sample {
+		return sum / float64(len(a)-1), nil
+	}
+
+	return sum / float64(len(a)), nil
+}
+
+// RollingBeta calculates beta of asset relatively to benchmark over every window of width windowWidth:
+// covariance(asset, benchmark) / variance(benchmark). Usually returns (see Returns) are used rather than prices.
+// Windowing and output data length are the same as in SMA.
+// expectedOutputDataLength is the required parameter for self-control.
+// It should be known BEFORE doing calculation, and if it is calculated incorrectly you can't handle obtained result in a right way.
+func RollingBeta(asset, benchmark []float64, windowWidth, expectedOutputDataLength int) ([]float64, error) {
+	if len(asset) != len(benchmark) {
+		return nil, errors.New("stat4trading::RollingBeta: both input data sets should be the same length")
+	}
+
+	outputDataLength := CalculateOutputDataLengthAfterMA(len(asset), windowWidth)
+
+	if windowWidth < 2 || outputDataLength <= 0 {
+		return nil, errors.New("stat4trading::RollingBeta: not enough data to calculate beta of specified window width (which should be at least 2), increase data set or reduce window width")
+	}
+
+	if expectedOutputDataLength != outputDataLength {
+		return nil, errors.New("stat4trading::RollingBeta: incorrectly calculated expected output data length")
+	}
+
+	processedData := make([]float64, outputDataLength)
+
+	for i := 0; i < outputDataLength; i++ {
+		assetWindow := asset[i : i+windowWidth]
+		benchmarkWindow := benchmark[i : i+windowWidth]
+
+		// Both covariance and variance are calculated the same way (population), so the normalization cancels out.
+		covariance, _ := Covariance(assetWindow, benchmarkWindow, false)
+		benchmarkVariance, _ := Covariance(benchmarkWindow, benchmarkWindow, false)
+
+		if isAlmostEqual(benchmarkVariance, 0.0) {
+			return nil, fmt.Errorf("stat4trading::RollingBeta: benchmark variance is zero in window starting at index %d", i)
+		}
+
+		processedData[i] = covariance / benchmarkVariance
+	}
+
+	return processedData, nil
+}
+
+// SeasonalAverage calculates average value at every phase 0..period-1 across all the cycles of the data set,
+// where phase of data[i] is i % period (e.g. average return by minute-of-hour for minute data and period = 60).
+// Data set should start at phase 0. If the last cycle is incomplete, its points are still included into averages of their phases.
+func SeasonalAverage(data []float64, period int) ([]float64, error) {
+	if period < 1 {
+		return nil, errors.New("stat4trading::SeasonalAverage: period should be positive")
+	}
+
+	if len(data) < period {
+		return nil, errors.New("stat4trading::SeasonalAverage: data set should contain at least one full period")
+	}
+
+	sums := make([]float64, period)
+	counts := make([]int, period)
+
+	for i := 0; i < len(data); i++ {
+		sums[i%period] += data[i]
+		counts[i%period]++
+	}
+
+	result := make([]float64, period)
+
+	for phase := 0; phase < period; phase++ {
+		result[phase] = sums[phase] / float64(counts[phase])
+	}
+
+	return result, nil
+}
+
+// Autocorrelation calculates autocorrelation of the data set at lags 0..maxLag:
+// r[k] = sum((x[t] - mean) * (x[t+k] - mean)) / sum((x[t] - mean)^2), so r[0] is always 1.
+// Slowly decaying autocorrelation means trend, negative r[1] means mean-reversion, and peaks at some lag mean cyclicity.
+func Autocorrelation(data []float64, maxLag int) ([]float64, error) {
+	if maxLag < 0 {
+		return nil, errors.New("stat4trading::Autocorrelation: maximum lag cannot be negative")
+	}
+
+	if maxLag >= len(data) {
+		return nil, errors.New("stat4trading::Autocorrelation: maximum lag should be less than data set length")
+	}
+
+	dataMean := mean(data)
+	denominator := 0.0
+
+	for i := 0; i < len(data); i++ {
+		denominator += (data[i] - dataMean) * (data[i] - dataMean)
+	}
+
+	if isAlmostEqual(denominator, 0.0) {
+		return nil, errors.New("stat4trading::Autocorrelation: variance of data set is zero")
+	}
+
+	result := make([]float64, maxLag+1)
+
+	for lag := 0; lag <= maxLag; lag++ {
+		sum := 0.0
+
+		for t := 0; t+lag < len(data); t++ {
+			sum += (data[t] - dataMean) * (data[t+lag] - dataMean)
+		}
+
+		result[lag] = sum / denominator
+	}
+
+	return result, nil
+}
+
+// DominantCycle finds the period (in samples) of the strongest cyclic component of the data set:
+// the linear trend is removed from the data, then the power spectrum is calculated with a simple DFT
+// (frequencies k/N for k = 2..N/2), and the period N/k of the frequency with the largest power is returned.
+// PLEASE NOTE: complexity is O(N^2), and only periods which fit into the data set at least twice can be detected.
+func DominantCycle(data []float64) (periodLengthSamples float64, err error) {
+	if len(data) < 4 {
+		return 0, errors.New("stat4trading::DominantCycle: at least 4 points are required to detect a cycle")
+	}
+
+	x := make([]float64, len(data))
+
+	for i := 0; i < len(data); i++ {
+		x[i] = float64(i)
+	}
+
+	trend, err := linearRegression(x, data)
+
+	if err != nil {
+		return 0, fmt.Errorf("stat4trading::DominantCycle: %w", err)
+	}
+
+	detrended := make([]float64, len(data))
+
+	for i := 0; i < len(data); i++ {
+		detrended[i] = data[i] - EvaluateLineAt(trend, x[i])
+	}
+
+	dataLength := float64(len(data))
+	maxPower := 0.0
+	dominantFrequencyIndex := 0
+
+	// k starts from 2, so the longest detectable period N/2 fits into the data set twice.
+	for k := 2; k <= len(data)/2; k++ {
+		re := 0.0
+		im := 0.0
+
+		for t := 0; t < len(data); t++ {
+			angle := 2 * math.Pi * float64(k) * float64(t) / dataLength
+			re += detrended[t] * math.Cos(angle)
+			im -= detrended[t] * math.Sin(angle)
+		}
+
+		power := re*re + im*im
+
+		if power > maxPower {
+			maxPower = power
+			dominantFrequencyIndex = k
+		}
+	}
+
+	if dominantFrequencyIndex == 0 || isAlmostEqual(maxPower, 0.0) {
+		return 0, errors.New("stat4trading::DominantCycle: there are no cyclic components in the data set")
+	}
+
+	return dataLength / float64(dominantFrequencyIndex), nil
+}
+
+// NormalPDF calculates probability density function of normal distribution with the given mean and standard deviation at x.
+func NormalPDF(x, mean, stdDev float64) (float64, error) {
+	if stdDev <= 0 {
+		return 0, errors.New("stat4trading::NormalPDF: standard deviation should be positive")
+	}
+
+	z := (x - mean) / stdDev
+
+	return math.Exp(-z*z/2) / (stdDev * math.Sqrt(2*math.Pi)), nil
+}
+
+// NormalCDF calculates cumulative distribution function of normal distribution with the given mean and standard deviation at x,
+// i.e. probability that a value is less than or equal to x.
+func NormalCDF(x, mean, stdDev float64) (float64, error) {
+	if stdDev <= 0 {
+		return 0, errors.New("stat4trading::NormalCDF: standard deviation should be positive")
+	}
+
+	return 0.5 * (1 + math.Erf((x-mean)/(stdDev*math.Sqrt2))), nil
+}
+
+// Dot calculates dot product of two vectors of the same length.
+func Dot(a, b []float64) (float64, error) {
+	if len(a) != len(b) {
+		return 0, errors.New("stat4trading::Dot: both vectors should be the same length")
+	}
+
+	sum := 0.0
+
+	for i := 0; i < len(a); i++ {
+		sum += a[i] * b[i]
+	}
+
+	return sum, nil
+}
+
+// Norm calculates Euclidean norm (length) of the vector.
+func Norm(a []float64) float64 {
+	sum := 0.0
+
+	for i := 0; i < len(a); i++ {
+		sum += a[i] * a[i]
+	}
+
+	return math.Sqrt(sum)
+}
+
+// EuclideanDistance calculates Euclidean distance between two series of the same length.
+func EuclideanDistance(a, b []float64) (float64, error) {
+	difference, err := Subtract(a, b)
+
+	if err != nil {
+		return 0, errors.New("stat4trading::EuclideanDistance: both input data sets should be the same length")
+	}
+
+	return Norm(difference), nil
+}
+
+// CosineSimilarity calculates cosine of the angle between two series of the same length treated as vectors:
+// 1 means the same shape (up to scale), 0 means no similarity, -1 means the opposite shape.
+func CosineSimilarity(a, b []float64) (float64, error) {
+	dotProduct, err := Dot(a, b)
+
+	if err != nil {
+		return 0, errors.New("stat4trading::CosineSimilarity: both input data sets should be the same length")
+	}
+
+	normA := Norm(a)
+	normB := Norm(b)
+
+	if isAlmostEqual(normA, 0.0) || isAlmostEqual(normB, 0.0) {
+		return 0, errors.New("stat4trading::CosineSimilarity: cosine similarity is not defined for vector with zero norm")
+	}
+
+	return dotProduct / (normA * normB), nil
+}
+
+// DTWDistance calculates Dynamic Time Warping distance between two series, which can have different lengths:
+// the minimal total cost (sum of absolute differences) of aligning points of one series to points of another,
+// when the series are allowed to be shifted and stretched in time.
+// PLEASE NOTE: complexity is O(len(a) * len(b)).
+func DTWDistance(a, b []float64) (float64, error) {
+	if len(a) == 0 || len(b) == 0 {
+		return 0, errors.New("stat4trading::DTWDistance: input data sets cannot be empty")
+	}
+
+	// Only two rows of the cost matrix are kept: previous (for a[i-1]) and current (for a[i]).
+	// Column 0 of every row is the "empty" prefix of b, which has infinite cost (except for the empty prefix of a).
+	previousRow := make([]float64, len(b)+1)
+	currentRow := make([]float64, len(b)+1)
+
+	for j := 1; j <= len(b); j++ {
+		previousRow[j] = math.Inf(1)
+	}
+
+	for i := 1; i <= len(a); i++ {
+		currentRow[0] = math.Inf(1)
+
+		for j := 1; j <= len(b); j++ {
+			cost := math.Abs(a[i-1] - b[j-1])
+			currentRow[j] = cost + math.Min(previousRow[j-1], math.Min(previousRow[j], currentRow[j-1]))
+		}
+
+		previousRow, currentRow = currentRow, previousRow
+	}
+
+	return previousRow[len(b)], nil
+}
+
+// MeanAbsoluteDeviation calculates average absolute deviation of values from their mean.
+func MeanAbsoluteDeviation(data []float64) (float64, error) {
+	if len(data) == 0 {
+		return 0, errors.New("stat4trading::MeanAbsoluteDeviation: input data set cannot be empty")
+	}
+
+	dataMean := mean(data)
+	sum := 0.0
+
+	for i := 0; i < len(data); i++ {
+		sum += math.Abs(data[i] - dataMean)
+	}
+
+	return sum / float64(len(data)), nil
+}
+
+// MedianAbsoluteDeviation calculates median of absolute deviations of values from their median.
+// It is much more resistant to outliers (e.g. flash spikes) than standard deviation or MeanAbsoluteDeviation.
+// PLEASE NOTE: the result is not scaled, multiply it by 1.4826 to get consistent estimation of standard deviation for normal distribution.
+func MedianAbsoluteDeviation(data []float64) (float64, error) {
+	if len(data) == 0 {
+		return 0, errors.New("stat4trading::MedianAbsoluteDeviation: input data set cannot be empty")
+	}
+
+	dataMedian := median(data)
+	deviations := make([]float64, len(data))
+
+	for i := 0; i < len(data); i++ {
+		deviations[i] = math.Abs(data[i] - dataMedian)
+	}
+
+	return median(deviations), nil
+}
+
+// Mode returns the most frequent value(s) of the data set (several values if there is a tie), sorted in ascending order.
+// If tolerance is 0, values are compared exactly. Otherwise values are grouped into buckets of width tolerance
+// ([0, tolerance), [tolerance, 2*tolerance), ...), and the centers of the most populated buckets are returned
+// (e.g. with tolerance = price tick it gives a crude volume-profile point of control). NaN values are skipped.
+func Mode(data []float64, tolerance float64) ([]float64, error) {
+	if len(data) == 0 {
+		return nil, errors.New("stat4trading::Mode: input data set cannot be empty")
+	}
+
+	if tolerance < 0 {
+		return nil, errors.New("stat4trading::Mode: tolerance cannot be negative")
+	}
+
+	counts := make(map[float64]int)
+	maxCount := 0
+
+	for i := 0; i < len(data); i++ {
+		if math.IsNaN(data[i]) {
+			continue
+		}
+
+		key := data[i]
+
+		if tolerance > 0 {
+			key = (math.Floor(data[i]/tolerance) + 0.5) * tolerance
+		}
+
+		counts[key]++
+
+		if counts[key] > maxCount {
+			maxCount = counts[key]
+		}
+	}
+
+	if maxCount == 0 {
+		return nil, errors.New("stat4trading::Mode: input data set consists of NaN values only")
+	}
+
+	result := make([]float64, 0)
+
+	for value, count := range counts {
+		if count == maxCount {
+			result = append(result, value)
+		}
+	}
+
+	sort.Float64s(result)
+
+	return result, nil
+}
+
+// Winsorize returns a new data set where values below the lowerPct percentile are replaced by the lowerPct percentile value,
+// and values above the upperPct percentile are replaced by the upperPct percentile value.
+// Percentiles are calculated with linear interpolation between the closest ranks, 0 <= lowerPct < upperPct <= 100.
+// NaN values are skipped when calculating percentiles and left as is in the result.
+// If data set consists of NaN values only, an error is returned.
+func Winsorize(data []float64, lowerPct, upperPct float64) ([]float64, error) {
+	if len(data) == 0 {
+		return nil, errors.New("stat4trading::Winsorize: input data set cannot be empty")
+	}
+
+	if lowerPct < 0 || lowerPct >= upperPct || upperPct > 100 {
+		return nil, errors.New("stat4trading::Winsorize: percentiles should satisfy 0 <= lowerPct < upperPct <= 100")
+	}
+
+	sortedData := make([]float64, 0, len(data))
+
+	for _, value := range data {
+		if !math.IsNaN(value) {
+			sortedData = append(sortedData, value)
+		}
+	}
+
+	if len(sortedData) == 0 {
+		return nil, errors.New("stat4trading::Winsorize: input data set consists of NaN values only")
+	}
+
+	sort.Float64s(sortedData)
+
+	return Clamp(data, percentileOfSorted(sortedData, lowerPct), percentileOfSorted(sortedData, upperPct)), nil
+}
+
+// TrueRange calculates true range of every bar: max(high, previous close) - min(low, previous close).
+// There is no previous close for the first bar, so out[0] = high[0] - low[0].
+// Output data length is the same as input.
+func TrueRange(high, low, close []float64) ([]float64, error) {
+	if len(high) != len(low) || len(high) != len(close) {
+		return nil, errors.New("stat4trading::TrueRange: high, low and close data sets should be the same length")
+	}
+
+	if len(close) == 0 {
+		return nil, errors.New("stat4trading::TrueRange: input data sets cannot be empty")
+	}
+
+	result := make([]float64, len(close))
+	result[0] = high[0] - low[0]
+
+	for i := 1; i < len(close); i++ {
+		result[i] = math.Max(high[i], close[i-1]) - math.Min(low[i], close[i-1])
+	}
+
+	return result, nil
+}
+
+// ADX - AverageDirectionalIndex
+// Calculates ADX together with +DI and -DI using Wilder's smoothing of true range and directional movement.
+// All three output slices are aligned to the most recent points and have the same length: len(close) - 2*period + 1,
+// because period points are consumed by smoothing of DM/TR (plus one point for the first difference)
+// and period-1 more points are consumed by smoothing of DX into ADX.
+func ADX(high, low, close []float64, period int) (adx, plusDI, minusDI []float64, err error) {
+	if len(high) != len(low) || len(high) != len(close) {
+		return nil, nil, nil, errors.New("stat4trading::ADX: high, low and close data sets should be the same length")
+	}
+
+	if period < 1 {
+		return nil, nil, nil, errors.New("stat4trading::ADX: period should be positive")
+	}
+
+	if len(close) < 2*period {
+		return nil, nil, nil, fmt.Errorf("stat4trading::ADX: not enough data to calculate ADX of period %d, at least %d points are required", period, 2*period)
+	}
+
+	trueRanges, err := TrueRange(high, low, close)
+
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("stat4trading::ADX: %w", err)
+	}
+
+	// Directional movement is defined starting from the second point (it needs previous values),
+	// so the first true range (which is calculated without previous close) is not used too.
+	trueRanges = trueRanges[1:]
+	plusDM := make([]float64, len(close)-1)
+	minusDM := make([]float64, len(close)-1)
+
+	for i := 1; i < len(close); i++ {
+		upMove := high[i] - high[i-1]
+		downMove := low[i-1] - low[i]
+
+		if upMove > downMove && upMove > 0 {
+			plusDM[i-1] = upMove
+		}
+
+		if downMove > upMove && downMove > 0 {
+			minusDM[i-1] = downMove
+		}
+	}
+
+	smoothedTR := wilderSmooth(trueRanges, period)
+	smoothedPlusDM := wilderSmooth(plusDM, period)
+	smoothedMinusDM := wilderSmooth(minusDM, period)
+
+	plusDI = make([]float64, len(smoothedTR))
+	minusDI = make([]float64, len(smoothedTR))
+	dx := make([]float64, len(smoothedTR))
+
+	for i := 0; i < len(smoothedTR); i++ {
+		if !isAlmostEqual(smoothedTR[i], 0.0) {
+			plusDI[i] = 100 * smoothedPlusDM[i] / smoothedTR[i]
+			minusDI[i] = 100 * smoothedMinusDM[i] / smoothedTR[i]
+		}
+
+		diSum := plusDI[i] + minusDI[i]
+
+		if !isAlmostEqual(diSum, 0.0) {
+			dx[i] = 100 * math.Abs(plusDI[i]-minusDI[i]) / diSum
+		}
+	}
+
+	adx = wilderSmooth(dx, period)
+
+	// Align DI to ADX (cut off the oldest points, which were consumed by ADX smoothing).
+	plusDI = plusDI[len(plusDI)-len(adx):]
+	minusDI = minusDI[len(minusDI)-len(adx):]
+
+	return adx, plusDI, minusDI, nil
+}
+
+// Aroon calculates Aroon Up and Aroon Down indicators:
+// AroonUp = 100 * (period - barsSinceHighestHigh) / period, AroonDown = 100 * (period - barsSinceLowestLow) / period,
+// where highest high and lowest low are searched in the lookback window of period+1 bars (current bar and period previous bars).
+// If the extremum occurs several times in the window, the most recent occurrence is used (the same as in TA-Lib).
+// Output data length is len(high) - period.
+func Aroon(high, low []float64, period int) (aroonUp, aroonDown []float64, err error) {
+	if len(high) != len(low) {
+		return nil, nil, errors.New("stat4trading::Aroon: high and low data sets should be the same length")
+	}
+
+	if period < 1 {
+		return nil, nil, errors.New("stat4trading::Aroon: period should be positive")
+	}
+
+	lookbackWidth := period + 1
+	outputDataLength := CalculateOutputDataLengthAfterMA(len(high), lookbackWidth)
+
+	if outputDataLength <= 0 {
+		return nil, nil, fmt.Errorf("stat4trading::Aroon: not enough data to calculate Aroon of period %d, at least %d points are required", period, lookbackWidth)
+	}
+
+	aroonUp = make([]float64, outputDataLength)
+	aroonDown = make([]float64, outputDataLength)
+
+	for i := 0; i < outputDataLength; i++ {
+		// FindMax/FindMin return the earliest occurrence, so they are applied to the reversed window to get the most recent one.
+		// Index inside the reversed window is 0 for the current bar and period for the oldest one, so it is exactly barsSince.
+		_, barsSinceHighestHigh, err := FindMax(Reverse(high[i : i+lookbackWidth]))
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("stat4trading::Aroon: %w", err)
+		}
+
+		_, barsSinceLowestLow, err := FindMin(Reverse(low[i : i+lookbackWidth]))
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("stat4trading::Aroon: %w", err)
+		}
+
+		aroonUp[i] = 100 * float64(period-barsSinceHighestHigh) / float64(period)
+		aroonDown[i] = 100 * float64(period-barsSinceLowestLow) / float64(period)
+	}
+
+	return aroonUp, aroonDown, nil
+}
+
+// VortexIndicator calculates VI+ and VI- lines:
+// +VM = |high - previous low|, -VM = |low - previous high|, VI+ = sum(+VM) / sum(TR), VI- = sum(-VM) / sum(TR),
+// where sums are calculated over the rolling window of period bars, and TR is TrueRange.
+// Movements need the previous bar, so the first bar is not used, and output data length is len(close) - period.
+// If sum of true ranges of the window is zero (absolutely flat market), both VI+ and VI- are 0.
+func VortexIndicator(high, low, close []float64, period int) (viPlus, viMinus []float64, err error) {
+	if len(high) != len(low) || len(high) != len(close) {
+		return nil, nil, errors.New("stat4trading::VortexIndicator: high, low and close data sets should be the same length")
+	}
+
+	if period < 1 {
+		return nil, nil, errors.New("stat4trading::VortexIndicator: period should be positive")
+	}
+
+	if len(close) < period+1 {
+		return nil, nil, fmt.Errorf("stat4trading::VortexIndicator: not enough data to calculate Vortex Indicator of period %d, at least %d points are required", period, period+1)
+	}
+
+	trueRanges, err := TrueRange(high, low, close)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("stat4trading::VortexIndicator: %w", err)
+	}
+
+	outputDataLength := len(close) - period
+	viPlus = make([]float64, outputDataLength)
+	viMinus = make([]float64, outputDataLength)
+
+	for i := 0; i < outputDataLength; i++ {
+		sumPlusVM := 0.0
+		sumMinusVM := 0.0
+		sumTR := 0.0
+
+		// Window of the output point i covers bars [i+1, i+period].
+		for j := i + 1; j <= i+period; j++ {
+			sumPlusVM += math.Abs(high[j] - low[j-1])
+			sumMinusVM += math.Abs(low[j] - high[j-1])
+			sumTR += trueRanges[j]
+		}
+
+		if isAlmostEqual(sumTR, 0.0) {
+			continue
+		}
+
+		viPlus[i] = sumPlusVM / sumTR
+		viMinus[i] = sumMinusVM / sumTR
+	}
+
+	return viPlus, viMinus, nil
+}
+
+// ElderRay calculates Elder Ray Index: bullPower = high - EMA(close, period), bearPower = low - EMA(close, period).
+// Output data length is the same as after EMA: len(close) - period + 1, high and low are aligned to the EMA by the most recent points.
+func ElderRay(high, low, close []float64, period int) (bullPower, bearPower []float64, err error) {
+	if len(high) != len(low) || len(high) != len(close) {
+		return nil, nil, errors.New("stat4trading::ElderRay: high, low and close data sets should be the same length")
+	}
+
+	if period < 1 {
+		return nil, nil, errors.New("stat4trading::ElderRay: period should be positive")
+	}
+
+	ema, err := EMA(close, period, CalculateOutputDataLengthAfterMA(len(close), period))
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("stat4trading::ElderRay: %w", err)
+	}
+
+	// The first period-1 points are consumed by EMA, so high and low are aligned to it by cutting them off.
+	bullPower, _ = Subtract(high[period-1:], ema)
+	bearPower, _ = Subtract(low[period-1:], ema)
+
+	return bullPower, bearPower, nil
+}
+
+// FisherTransform calculates Ehlers Fisher Transform of the median price (high+low)/2:
+// median price is normalized to [-1, 1] over the rolling window of period bars and smoothed: x = 0.66*(normalized - 0.5) + 0.67*previous x,
+// then clamped to [-0.999, 0.999] to avoid infinities, and transformed: fisher = 0.5*ln((1+x)/(1-x)) + 0.5*previous fisher.
+// Trigger is the fisher line lagged by one bar. To have trigger defined for every output point, the first fisher value is cut off,
+// so output data length is len(high) - period.
+// If the window is flat (highest = lowest median price), median price is considered to be in the middle of the range.
+func FisherTransform(high, low []float64, period int) (fisher, trigger []float64, err error) {
+	if len(high) != len(low) {
+		return nil, nil, errors.New("stat4trading::FisherTransform: high and low data sets should be the same length")
+	}
+
+	if period < 1 {
+		return nil, nil, errors.New("stat4trading::FisherTransform: period should be positive")
+	}
+
+	if len(high) < period+1 {
+		return nil, nil, fmt.Errorf("stat4trading::FisherTransform: not enough data to calculate Fisher Transform of period %d, at least %d points are required", period, period+1)
+	}
+
+	const maxNormalizedValue = 0.999
+
+	medianPrices := make([]float64, len(high))
+
+	for i := 0; i < len(high); i++ {
+		medianPrices[i] = (high[i] + low[i]) / 2
+	}
+
+	rollingLength := CalculateOutputDataLengthAfterMA(len(medianPrices), period)
+	highest, _ := RollingMax(medianPrices, period, rollingLength)
+	lowest, _ := RollingMin(medianPrices, period, rollingLength)
+
+	fullFisher := make([]float64, rollingLength)
+	previousValue := 0.0
+	previousFisher := 0.0
+
+	for i := 0; i < rollingLength; i++ {
+		// Median price which corresponds to the window is the last one in the window.
+		price := medianPrices[i+period-1]
+		position := 0.5
+
+		if !isAlmostEqual(highest[i], lowest[i]) {
+			position = (price - lowest[i]) / (highest[i] - lowest[i])
+		}
+
+		value := 0.66*(position-0.5) + 0.67*previousValue
+		value = math.Max(-maxNormalizedValue, math.Min(maxNormalizedValue, value))
+
+		fullFisher[i] = 0.5*math.Log((1+value)/(1-value)) + 0.5*previousFisher
+
+		previousValue = value
+		previousFisher = fullFisher[i]
+	}
+
+	return fullFisher[1:], fullFisher[:rollingLength-1], nil
+}
+
+// StochasticOf applies Stochastic %K formula to any series (not only price, e.g. to RSI to get Stochastic RSI):
+// 100 * (x - lowest) / (highest - lowest), where x is the last value of the window of period values, lowest and highest are its minimum and maximum.
+// If the window is flat (highest = lowest), value is considered to be in the middle of the range, i.e. 50.
+// Windowing and output data length are the same as in SMA.
+func StochasticOf(data []float64, period int) ([]float64, error) {
+	if period < 1 {
+		return nil, errors.New("stat4trading::StochasticOf: period should be positive")
+	}
+
+	outputDataLength := CalculateOutputDataLengthAfterMA(len(data), period)
+
+	if outputDataLength <= 0 {
+		return nil, errors.New("stat4trading::StochasticOf: not enough data to calculate Stochastic of specified period, increase data set or reduce period")
+	}
+
+	highest, _ := RollingMax(data, period, outputDataLength)
+	lowest, _ := RollingMin(data, period, outputDataLength)
+
+	result := make([]float64, outputDataLength)
+
+	for i := 0; i < outputDataLength; i++ {
+		result[i] = 50
+
+		if !isAlmostEqual(highest[i], lowest[i]) {
+			result[i] = 100 * (data[i+period-1] - lowest[i]) / (highest[i] - lowest[i])
+		}
+	}
+
+	return result, nil
+}
+
+// StochasticRSI calculates Stochastic RSI: Stochastic (see StochasticOf) of RSI with Wilder's smoothing, smoothed by SMA:
+// k = SMA(StochasticOf(RSI(close, rsiPeriod), stochPeriod), kSmooth), d = SMA(k, dSmooth). Both are in range [0, 100].
+// Every step shortens the data: RSI by rsiPeriod, Stochastic by stochPeriod-1, and every SMA by its window width - 1,
+// so d is len(close) - rsiPeriod - stochPeriod - kSmooth - dSmooth + 3 points long. k is trimmed FROM THE FRONT to the same length,
+// so k[i] and d[i] are aligned with each other and with close[len(close) - len(d) + i].
+// Use kSmooth = 1 and/or dSmooth = 1 to disable corresponding smoothing.
+func StochasticRSI(close []float64, rsiPeriod, stochPeriod, kSmooth, dSmooth int) (k, d []float64, err error) {
+	if rsiPeriod < 1 || stochPeriod < 1 || kSmooth < 1 || dSmooth < 1 {
+		return nil, nil, errors.New("stat4trading::StochasticRSI: all periods should be positive")
+	}
+
+	outputDataLength := len(close) - rsiPeriod - stochPeriod - kSmooth - dSmooth + 3
+
+	if outputDataLength <= 0 {
+		return nil, nil, fmt.Errorf("stat4trading::StochasticRSI: not enough data to calculate Stochastic RSI of specified periods, at least %d points are required", rsiPeriod+stochPeriod+kSmooth+dSmooth-2)
+	}
+
+	stochastic, err := StochasticOf(calculateRSI(close, rsiPeriod), stochPeriod)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("stat4trading::StochasticRSI: %w", err)
+	}
+
+	k, err = SMA(stochastic, kSmooth, CalculateOutputDataLengthAfterMA(len(stochastic), kSmooth))
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("stat4trading::StochasticRSI: %w", err)
+	}
+
+	d, err = SMA(k, dSmooth, outputDataLength)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("stat4trading::StochasticRSI: %w", err)
+	}
+
+	return k[len(k)-len(d):], d, nil
+}
+
+// CrossDirection describes direction in which investigated graph crosses the reference graph.
+type CrossDirection int
+
+const (
+	NoCross CrossDirection = iota
+	BottomToTop
+	TopToBottom
+)
+
+func (d CrossDirection) String() string {
+	switch d {
+	case BottomToTop:
+		return "BOTTOM-TO-TOP"
+	case TopToBottom:
+		return "TOP-TO-BOTTOM"
+	case NoCross:
+		return "NO-CROSS"
+	}
+
+	return fmt.Sprintf("CrossDirection(%d)", int(d))
+}
+
+// FindIntersectionDirections returns direction of crossing for every index of the input data sets (NoCross if there is no crossing).
+// Graphs which touch each other, or go together with equal values for several points, are handled as follows:
+//   - if after separation the investigated graph is on the OTHER side of the reference graph compared to where it was before touching,
+//     the crossing is reported at the index where graphs separate;
+//   - if after separation the investigated graph is on the SAME side, it is a touch (tangent), and no crossing is reported.
+//
+// If graphs are equal from the very beginning, the first separation is not a crossing, because there is no known previous side.
+func FindIntersectionDirections(referenceGraph []float64, investigatedGraph []float64) ([]CrossDirection, error) {
+	if len(referenceGraph) != len(investigatedGraph) {
+		return nil, errors.New("stat4trading::FindIntersectionDirections: both input data sets should be the same length")
+	}
+
+	return detectCrossDirections(referenceGraph, investigatedGraph), nil
+}
+
+// Crossover describes a single crossing of investigated graph over the reference graph.
+// Index is the index of the first point AFTER the crossing, Direction is the same as returned by FindIntersectionDirections,
+// and Point is the exact intersection point of two segments [Index-1, Index], so Point.X is fractional and lies between Index-1 and Index.
+// If graphs were going together with equal values before the crossing, Point is the last point of equality (Point.X = Index-1).
+type Crossover struct {
+	Index     int
+	Direction CrossDirection
+	Point     PointCoordinates
+}
+
+// FindCrossovers finds all the crossings of investigatedGraph over referenceGraph.
+// In contrast to FindIntersectionDirections, it returns only the crossings themselves (not a value per every index),
+// and for every crossing it calculates the intersection point between two samples, which gives precise crossover price.
+// Index of data point is used as X coordinate.
+func FindCrossovers(referenceGraph []float64, investigatedGraph []float64) ([]Crossover, error) {
+	if len(referenceGraph) != len(investigatedGraph) {
+		return nil, errors.New("stat4trading::FindCrossovers: both input data sets should be the same length")
+	}
+
+	directions := detectCrossDirections(referenceGraph, investigatedGraph)
+	result := make([]Crossover, 0)
+
+	for i, direction := range directions {
+		if direction == NoCross {
+			continue
+		}
+
+		result = append(result, Crossover{
+			Index:     i,
+			Direction: direction,
+			Point:     findLocalIntersectionPoint(referenceGraph, investigatedGraph, i),
+		})
+	}
+
+	return result, nil
+}
+
+// CrossoverEvent describes a single crossing together with values of both graphs at the crossing index.
+// Index and Direction are the same as in Crossover (Direction.String() gives "BOTTOM-TO-TOP" / "TOP-TO-BOTTOM" for logging).
+type CrossoverEvent struct {
+	Index             int
+	Direction         CrossDirection
+	ReferenceValue    float64
+	InvestigatedValue float64
+}
+
+// CrossoverReport finds all the crossings of investigated graph over the reference graph (the same way as FindIntersectionDirections)
+// and reports values of both graphs at every crossing index.
+func CrossoverReport(reference, investigated []float64) ([]CrossoverEvent, error) {
+	if len(reference) != len(investigated) {
+		return nil, errors.New("stat4trading::CrossoverReport: both input data sets should be the same length")
+	}
+
+	directions := detectCrossDirections(reference, investigated)
+	result := make([]CrossoverEvent, 0)
+
+	for i, direction := range directions {
+		if direction == NoCross {
+			continue
+		}
+
+		result = append(result, CrossoverEvent{
+			Index:             i,
+			Direction:         direction,
+			ReferenceValue:    reference[i],
+			InvestigatedValue: investigated[i],
+		})
+	}
+
+	return result, nil
+}
+
+// ZeroCrossingIndices returns indexes where the data set crosses zero (sign of value flips, comparing to the previous non-zero value).
+// Exact zero values are handled the same way as equal values in FindIntersectionDirections:
+// a touch of zero (when the sign after it is the same as before) is NOT a crossing,
+// and a crossing through one or several zero values is reported at the index of the first non-zero value after them.
+func ZeroCrossingIndices(data []float64) []int {
+	directions := detectCrossDirections(make([]float64, len(data)), data)
+	result := make([]int, 0)
+
+	for i, direction := range directions {
+		if direction != NoCross {
+			result = append(result, i)
+		}
+	}
+
+	return result
+}
+
+// CountZeroCrossings returns number of zero crossings in the data set, see ZeroCrossingIndices for details.
+func CountZeroCrossings(data []float64) int {
+	return len(ZeroCrossingIndices(data))
+}
+
+// GenerateSignals converts crossings of two moving averages into trading signals, suitable for a backtest loop:
+// +1 when fast crosses slow from below (golden cross), -1 when fast crosses slow from above (death cross), 0 otherwise.
+// Signals are aligned with input data sets by index, crossings are detected the same way as in FindIntersectionDirections.
+func GenerateSignals(fast, slow []float64) ([]int, error) {
+	directions, err := FindIntersectionDirections(slow, fast)
+
+	if err != nil {
+		return nil, errors.New("stat4trading::GenerateSignals: both input data sets should be the same length")
+	}
+
+	result := make([]int, len(directions))
+
+	for i, direction := range directions {
+		switch direction {
+		case BottomToTop:
+			result[i] = 1
+		case TopToBottom:
+			result[i] = -1
+		}
+	}
+
+	return result, nil
+}
+
+// BacktestResult is the result of BacktestSignals.
+type BacktestResult struct {
+	// CumulativeReturn is the total return of the strategy after fees, e.g. 0.15 means +15%.
+	CumulativeReturn float64
+	// Trades is the number of closed trades (round trips: entry + exit).
+	Trades int
+	// WinRate is the share of trades with positive return after fees, in range [0, 1] (0 if there are no trades).
+	WinRate float64
+	// MaxDrawdown is the largest peak-to-trough decline of the equity curve as a positive share, e.g. 0.2 means -20%.
+	MaxDrawdown float64
+}
+
+// BacktestSignals evaluates a long-only strategy driven by signals (as produced by GenerateSignals):
+// +1 opens a position at the price of the same bar (if there is no open position), -1 closes it, 0 does nothing.
+// Position which is still open at the last bar is closed at the last price.
+// feeRate (e.g. 0.001 for 0.1%) is charged on every entry and every exit.
+// Equity is marked to market on every bar to calculate maximum drawdown.
+func BacktestSignals(prices []float64, signals []int, feeRate float64) (BacktestResult, error) {
+	if len(prices) != len(signals) {
+		return BacktestResult{}, errors.New("stat4trading::BacktestSignals: prices and signals should be the same length")
+	}
+
+	if len(prices) == 0 {
+		return BacktestResult{}, errors.New("stat4trading::BacktestSignals: input data sets cannot be empty")
+	}
+
+	if feeRate < 0 || feeRate >= 1 {
+		return BacktestResult{}, errors.New("stat4trading::BacktestSignals: fee rate should be in range [0, 1)")
+	}
+
+	for i := 0; i < len(prices); i++ {
+		if prices[i] <= 0 {
+			return BacktestResult{}, fmt.Errorf("stat4trading::BacktestSignals: prices should be positive, got non-positive price at index %d", i)
+		}
+	}
+
+	result := BacktestResult{}
+	equity := 1.0
+	peakEquity := 1.0
+	inPosition := false
+	entryPrice := 0.0
+	entryEquity := 0.0
+	wins := 0
+
+	closePosition := func(exitPrice float64) {
+		equity = equity * exitPrice / entryPrice * (1 - feeRate)
+		inPosition = false
+		result.Trades++
+
+		if equity > entryEquity {
+			wins++
+		}
+	}
+
+	for i := 0; i < len(prices); i++ {
+		if !inPosition && signals[i] > 0 {
+			entryEquity = equity
+			equity *= 1 - feeRate
+			entryPrice = prices[i]
+			inPosition = true
+		} else if inPosition && signals[i] < 0 {
+			closePosition(prices[i])
+		}
+
+		markedEquity := equity
+
+		if inPosition {
+			markedEquity = equity * prices[i] / entryPrice
+		}
+
+		if markedEquity > peakEquity {
+			peakEquity = markedEquity
+		}
+
+		drawdown := (peakEquity - markedEquity) / peakEquity
+
+		if drawdown > result.MaxDrawdown {
+			result.MaxDrawdown = drawdown
+		}
+	}
+
+	if inPosition {
+		closePosition(prices[len(prices)-1])
+
+		drawdown := (peakEquity - equity) / peakEquity
+
+		if drawdown > result.MaxDrawdown {
+			result.MaxDrawdown = drawdown
+		}
+	}
+
+	result.CumulativeReturn = equity - 1
+
+	if result.Trades > 0 {
+		result.WinRate = float64(wins) / float64(result.Trades)
+	}
+
+	return result, nil
+}
+
+// OptimalMAWindow backtests a simple long-only strategy "price vs moving average" for every window width in range [minWindow, maxWindow]
+// and returns the window with the highest Sharpe ratio: position is opened when price crosses MA from below
+// and closed when price crosses MA from above (see GenerateSignals), position is held at close of the signal bar.
+// Sharpe ratio is calculated from per-bar strategy returns with zero risk-free rate and WITHOUT annualization (periodsPerYear = 1),
+// which doesn't affect comparison between windows. Fees are not taken into account.
+// Windows which can't be evaluated (e.g. MA can't be calculated, or strategy returns have zero variance because there were no trades) are skipped.
+// If several windows have the same Sharpe ratio, the smallest one is returned.
+// WARNING: choosing parameters by the best result on historical data is prone to overfitting, validate the result on out-of-sample data.
+func OptimalMAWindow(prices []float64, minWindow, maxWindow int, maType MAType) (bestWindow int, bestSharpe float64, err error) {
+	if minWindow < 1 || maxWindow < minWindow {
+		return 0, 0, errors.New("stat4trading::OptimalMAWindow: window range should satisfy 1 <= minWindow <= maxWindow")
+	}
+
+	if maxWindow >= len(prices) {
+		return 0, 0, errors.New("stat4trading::OptimalMAWindow: max window should be less than the length of prices")
+	}
+
+	priceReturns, err := Returns(prices, false)
+
+	if err != nil {
+		return 0, 0, fmt.Errorf("stat4trading::OptimalMAWindow: %w", err)
+	}
+
+	bestSharpe = math.Inf(-1)
+
+	for windowWidth := minWindow; windowWidth <= maxWindow; windowWidth++ {
+		movingAverage, err := ComputeMA(prices, windowWidth, maType)
+
+		if err != nil || len(movingAverage) < 3 {
+			continue
+		}
+
+		offset := len(prices) - len(movingAverage)
+		signals, err := GenerateSignals(prices[offset:], movingAverage)
+
+		if err != nil {
+			continue
+		}
+
+		strategyReturns := make([]float64, len(signals)-1)
+		inPosition := false
+
+		for i := 0; i < len(strategyReturns); i++ {
+			if signals[i] > 0 {
+				inPosition = true
+			} else if signals[i] < 0 {
+				inPosition = false
+			}
+
+			if inPosition {
+				strategyReturns[i] = priceReturns[offset+i]
+			}
+		}
+
+		sharpe, err := SharpeRatio(strategyReturns, 0, 1)
+
+		if err != nil {
+			continue
+		}
+
+		if sharpe > bestSharpe {
+			bestWindow = windowWidth
+			bestSharpe = sharpe
+		}
+	}
+
+	if bestWindow == 0 {
+		return 0, 0, errors.New("stat4trading::OptimalMAWindow: none of the windows in the range could be evaluated")
+	}
+
+	return bestWindow, bestSharpe, nil
+}
+
+// CrossoverHitStats is the result of CrossoverStats. Up* fields describe golden crosses (fast crosses slow from below),
+// Down* fields describe death crosses (fast crosses slow from above).
+type CrossoverHitStats struct {
+	// UpCrosses and DownCrosses are the numbers of evaluated crossings
+	// (crossings which are closer than horizon bars to the end of data are not evaluated).
+	UpCrosses   int
+	DownCrosses int
+	// UpHitRate is the share of golden crosses after which price went UP in horizon bars, in range [0, 1].
+	UpHitRate float64
+	// DownHitRate is the share of death crosses after which price went DOWN in horizon bars, in range [0, 1].
+	DownHitRate float64
+	// UpAvgForwardReturn and DownAvgForwardReturn are average price returns over horizon bars after the crossing
+	// (not adjusted to the signal direction, so a good death cross has negative average return).
+	UpAvgForwardReturn   float64
+	DownAvgForwardReturn float64
+}
+
+// CrossoverStats evaluates crossings of fast and slow moving averages (detected the same way as in FindIntersectionDirections):
+// for every crossing at index i it checks whether price moved in the signaled direction at index i+horizon.
+// All three data sets should be the same length and aligned by index.
+func CrossoverStats(fast, slow, futurePrices []float64, horizon int) (CrossoverHitStats, error) {
+	if len(fast) != len(slow) || len(fast) != len(futurePrices) {
+		return CrossoverHitStats{}, errors.New("stat4trading::CrossoverStats: fast, slow and price data sets should be the same length")
+	}
+
+	if horizon < 1 {
+		return CrossoverHitStats{}, errors.New("stat4trading::CrossoverStats: horizon should be positive")
+	}
+
+	signals, err := GenerateSignals(fast, slow)
+
+	if err != nil {
+		return CrossoverHitStats{}, fmt.Errorf("stat4trading::CrossoverStats: %w", err)
+	}
+
+	result := CrossoverHitStats{}
+	upHits, downHits := 0, 0
+
+	for i := 0; i+horizon < len(signals); i++ {
+		if signals[i] == 0 {
+			continue
+		}
+
+		if futurePrices[i] <= 0 {
+			return CrossoverHitStats{}, fmt.Errorf("stat4trading::CrossoverStats: prices should be positive, got non-positive price at index %d", i)
+		}
+
+		forwardReturn := futurePrices[i+horizon]/futurePrices[i] - 1
+
+		if signals[i] > 0 {
+			result.UpCrosses++
+			result.UpAvgForwardReturn += forwardReturn
+
+			if forwardReturn > 0 {
+				upHits++
+			}
+		} else {
+			result.DownCrosses++
+			result.DownAvgForwardReturn += forwardReturn
+
+			if forwardReturn < 0 {
+				downHits++
+			}
+		}
+	}
+
+	if result.UpCrosses > 0 {
+		result.UpHitRate = float64(upHits) / float64(result.UpCrosses)
+		result.UpAvgForwardReturn /= float64(result.UpCrosses)
+	}
+
+	if result.DownCrosses > 0 {
+		result.DownHitRate = float64(downHits) / float64(result.DownCrosses)
+		result.DownAvgForwardReturn /= float64(result.DownCrosses)
+	}
+
+	return result, nil
+}
+
+// AverageCrossoverLag calculates average number of bars between consecutive crossings of fast and slow moving averages
+// (crossings are detected the same way as in FindIntersectionDirections, so up and down crossings always alternate).
+// Small values mean the pair is too whippy, large values mean it is sluggish.
+// At least 2 crossings are required.
+func AverageCrossoverLag(fast, slow []float64) (float64, error) {
+	if len(fast) != len(slow) {
+		return 0, errors.New("stat4trading::AverageCrossoverLag: both input data sets should be the same length")
+	}
+
+	crossingIndexes := make([]int, 0)
+
+	for i, direction := range detectCrossDirections(slow, fast) {
+		if direction != NoCross {
+			crossingIndexes = append(crossingIndexes, i)
+		}
+	}
+
+	if len(crossingIndexes) < 2 {
+		return 0, errors.New("stat4trading::AverageCrossoverLag: at least 2 crossings are required to calculate average lag")
+	}
+
+	// Sum of spacings between consecutive crossings is simply the distance between the first and the last ones.
+	totalSpacing := crossingIndexes[len(crossingIndexes)-1] - crossingIndexes[0]
+
+	return float64(totalSpacing) / float64(len(crossingIndexes)-1), nil
+}
+
+// detectCrossDirections walks through both graphs (which should be the same length) and detects crossing direction at every index.
+// It remembers the side where investigated graph was before the last point of equality, so crossings over flat segments are not missed.
+func detectCrossDirections(referenceGraph []float64, investigatedGraph []float64) []CrossDirection {
+	result := make([]CrossDirection, len(referenceGraph))
+
+	// Side of investigated graph relatively to the reference graph: -1 = below, 1 = above, 0 = not known yet.
+	previousSide := 0
+
+	for i := 0; i < len(referenceGraph); i++ {
+		result[i] = NoCross
+
+		currentSide := 0
+
+		if investigatedGraph[i] > referenceGraph[i] {
+			currentSide = 1
+		} else if investigatedGraph[i] < referenceGraph[i] {
+			currentSide = -1
+		}
+
+		if currentSide == 0 {
+			// Graphs are touching, remember the side they were before and wait for separation.
+			continue
+		}
+
+		if previousSide == -1 && currentSide == 1 {
+			result[i] = BottomToTop
+		} else if previousSide == 1 && currentSide == -1 {
+			result[i] = TopToBottom
+		}
+
+		previousSide = currentSide
+	}
+
+	return result
+}
+
+// findLocalIntersectionPoint solves two segments [i-1, i] of both graphs (index is used as X coordinate).
+// It should be called only when it is known that the segments are crossing.
+// If graphs are equal at i-1 (they were touching before separation), the point i-1 itself is returned.
+func findLocalIntersectionPoint(referenceGraph []float64, investigatedGraph []float64, i int) PointCoordinates {
+	// Distance between graphs at the start and at the end of the segment.
+	// Linear interpolation of the distance gives the position where it becomes zero.
+	distanceAtStart := investigatedGraph[i-1] - referenceGraph[i-1]
+	distanceAtEnd := investigatedGraph[i] - referenceGraph[i]
+
+	t := distanceAtStart / (distanceAtStart - distanceAtEnd)
+
+	return PointCoordinates{
+		X: float64(i-1) + t,
+		Y: referenceGraph[i-1] + t*(referenceGraph[i]-referenceGraph[i-1]),
+	}
+}
+
+// FindIntersectionPointOfTwoSegments - tries to solve a system of two linear equations and returns 3 parameters:
+//  1. Coordinates of intersection point if they are exist
+//  2. Boolean indicating if solution exists, or it does not
+//     for example, solution does not exist if:
+//     a) two lines are parallel,
+//     b) if intersection point exists, but it is outside of the common projection
+//  3. Error in other abnormal situation.
+//
+// PLEASE NOTE: This function searches intersection of SEGMENTS, NOT LINES!!!
+func FindIntersectionPointOfTwoSegments(lineA LineDefinedByTwoPoints, lineB LineDefinedByTwoPoints) (PointCoordinates, bool, error) {
+	// lineA: y = kx+b
+	// lineB: y = mx+c
+
+	deltaXA := lineA.PointB.X - lineA.PointA.X
+	deltaXB := lineB.PointB.X - lineB.PointA.X
+
+	if deltaXA <= 0 || deltaXB <= 0 || isAlmostEqual(lineA.PointA.X, lineA.PointB.X) || isAlmostEqual(lineB.PointA.X, lineB.PointB.X) {
+		return PointCoordinates{}, false, errors.New("stat4trading::FindIntersectionPointOfTwoLines error: deltaX = x2-x1 = 0, while it should not be so. There is an error in input data")
+	}
+
+	k := (lineA.PointB.Y - lineA.PointA.Y) / deltaXA
+	b := lineA.PointA.Y - k*lineA.PointA.X
+
+	m := (lineB.PointB.Y - lineB.PointA.Y) / deltaXB
+	c := lineB.PointA.Y - m*lineB.PointA.X
+
+	if isAlmostEqual(k, m) {
+		// Solution DOES NOT exist, but the situation IS REGULAR!
+		return PointCoordinates{}, false, nil
+	}
+
+	x := (c - b) / (k - m)
+
+	y1 := k*x + b
+	y2 := m*x + c
+
+	// This case should never happen, and here just for self-control:
+	if math.Abs(y1-y2) > selfControlTolerance {
+		return PointCoordinates{}, false, fmt.Errorf("stat4trading::FindIntersectionPointOfTwoSegments: self-control failed: error in linear equation solving logic, Y1 = %.10f, Y2 = %.10f", y1, y2)
+	}
+
+	// We found that LINES are intersect, now let's check if SEGMENTS are intersect!
+	commonProjectionStartX, _, _ := FindMax([]float64{lineA.PointA.X, lineB.PointA.X})
+	commonProjectionEndX, _, _ := FindMin([]float64{lineA.PointB.X, lineB.PointB.X})
+
+	// Common projection is FROM commonProjectionStartX TO commonProjectionEndX, not vise versa!
+	if commonProjectionStartX <= x && x <= commonProjectionEndX {
+		return PointCoordinates{X: x, Y: y1}, true, nil
+	}
+
+	return PointCoordinates{}, false, nil
+}
+
+func FindEquationOfLineGivenByTwoPoints(lineByTwoPoints LineDefinedByTwoPoints) (LineDefinedByParameters, error) {
+	// We solve system of 2 equations:
+	// ax1 + b = y1
+	// ax2 + b = y2
+	// We should find parameters a and b, so we'll know the equation of line
+
+	// Main Determinant:
+	// x1 1
+	// x2 1
+
+	// Determinant A:
+	// y1 1
+	// y2 1
+
+	// Determinant B:
+	// x1 y1
+	// x2 y2
+
+	// a = DetA/MainDet
+	// b = DetB/MainDet
+
+	detMain := lineByTwoPoints.PointA.X - lineByTwoPoints.PointB.X
+
+	if isAlmostEqual(lineByTwoPoints.PointA.X, lineByTwoPoints.PointB.X) {
+		return LineDefinedByParameters{}, errors.New("x1 and x2 are the same. Unable to unambiguously define a line")
+	}
+
+	detA := lineByTwoPoints.PointA.Y - lineByTwoPoints.PointB.Y
+	detB := lineByTwoPoints.PointA.X*lineByTwoPoints.PointB.Y - lineByTwoPoints.PointA.Y*lineByTwoPoints.PointB.X
+
+	a := detA / detMain
+	b := detB / detMain
+
+	return LineDefinedByParameters{ParamA: a, ParamB: b}, nil
+}
+
+// WeightedLinearRegression fits a line y = ax + b to the points (x[i], y[i]) with weighted least squares method,
+// where every point has its own non-negative weight (e.g. recent bars can be weighted more).
+func WeightedLinearRegression(x, y, weights []float64) (LineDefinedByParameters, error) {
+	if len(x) != len(y) || len(x) != len(weights) {
+		return LineDefinedByParameters{}, errors.New("stat4trading::WeightedLinearRegression: x, y and weights should be the same length")
+	}
+
+	if len(x) < 2 {
+		return LineDefinedByParameters{}, errors.New("stat4trading::WeightedLinearRegression: at least 2 points are required to fit a line")
+	}
+
+	weightsSum := 0.0
+	weightedSumX := 0.0
+	weightedSumY := 0.0
+
+	for i := 0; i < len(x); i++ {
+		if weights[i] < 0 {
+			return LineDefinedByParameters{}, fmt.Errorf("stat4trading::WeightedLinearRegression: weights cannot be negative, got negative weight at index %d", i)
+		}
+
+		weightsSum += weights[i]
+		weightedSumX += weights[i] * x[i]
+		weightedSumY += weights[i] * y[i]
+	}
+
+	if isAlmostEqual(weightsSum, 0.0) {
+		return LineDefinedByParameters{}, errors.New("stat4trading::WeightedLinearRegression: all the weights are zero")
+	}
+
+	meanX := weightedSumX / weightsSum
+	meanY := weightedSumY / weightsSum
+	sxx := 0.0
+	sxy := 0.0
+
+	for i := 0; i < len(x); i++ {
+		sxx += weights[i] * (x[i] - meanX) * (x[i] - meanX)
+		sxy += weights[i] * (x[i] - meanX) * (y[i] - meanY)
+	}
+
+	if isAlmostEqual(sxx, 0.0) {
+		return LineDefinedByParameters{}, errors.New("stat4trading::WeightedLinearRegression: all the (weighted) x values are the same. Unable to unambiguously define a line")
+	}
+
+	a := sxy / sxx
+	b := meanY - a*meanX
+
+	return LineDefinedByParameters{ParamA: a, ParamB: b}, nil
+}
+
+// SlopeStandardError calculates standard error of the slope of the line, fitted to the points (x[i], y[i]) with least squares method:
+// SE = sqrt( sum(residual^2) / (n-2) / sum((x - mean(x))^2) ).
+// Slope is considered statistically significant if it is several times (usually > 2) larger than its standard error.
+func SlopeStandardError(x, y []float64, line LineDefinedByParameters) (float64, error) {
+	if len(x) != len(y) {
+		return 0, errors.New("stat4trading::SlopeStandardError: x and y should be the same length")
+	}
+
+	if len(x) < 3 {
+		return 0, errors.New("stat4trading::SlopeStandardError: at least 3 points are required to estimate standard error of the slope")
+	}
+
+	meanX := mean(x)
+	sumSquaredResiduals := 0.0
+	sxx := 0.0
+
+	for i := 0; i < len(x); i++ {
+		residual := y[i] - EvaluateLineAt(line, x[i])
+		sumSquaredResiduals += residual * residual
+		sxx += (x[i] - meanX) * (x[i] - meanX)
+	}
+
+	if isAlmostEqual(sxx, 0.0) {
+		return 0, errors.New("stat4trading::SlopeStandardError: all the x values are the same")
+	}
+
+	return math.Sqrt(sumSquaredResiduals / float64(len(x)-2) / sxx), nil
+}
+
+// TheilSen fits a line y = ax + b to the points (x[i], y[i]) with robust Theil-Sen estimator:
+// slope is the median of slopes between all pairs of points (pairs with the same x are skipped),
+// and intercept is the median of y[i] - slope*x[i]. Up to ~29% of outliers don't affect the result, in contrast to least squares.
+// PLEASE NOTE: complexity is O(n^2) of the number of points.
+func TheilSen(x, y []float64) (LineDefinedByParameters, error) {
+	if len(x) != len(y) {
+		return LineDefinedByParameters{}, errors.New("stat4trading::TheilSen: x and y should be the same length")
+	}
+
+	if len(x) < 2 {
+		return LineDefinedByParameters{}, errors.New("stat4trading::TheilSen: at least 2 points are required to fit a line")
+	}
+
+	slopes := make([]float64, 0, len(x)*(len(x)-1)/2)
+
+	for i := 0; i < len(x); i++ {
+		for j := i + 1; j < len(x); j++ {
+			if isAlmostEqual(x[i], x[j]) {
+				continue
+			}
+
+			slopes = append(slopes, (y[j]-y[i])/(x[j]-x[i]))
+		}
+	}
+
+	if len(slopes) == 0 {
+		return LineDefinedByParameters{}, errors.New("stat4trading::TheilSen: all the x values are the same. Unable to unambiguously define a line")
+	}
+
+	a := median(slopes)
+	intercepts := make([]float64, len(x))
+
+	for i := 0; i < len(x); i++ {
+		intercepts[i] = y[i] - a*x[i]
+	}
+
+	return LineDefinedByParameters{ParamA: a, ParamB: median(intercepts)}, nil
+}
+
+// PolynomialRegression fits a polynomial of the given degree to the points (x[i], y[i]) with least squares method.
+// Coefficients are returned from the lowest to the highest order: y = c[0] + c[1]*x + c[2]*x^2 + ... + c[degree]*x^degree.
+// The system of normal equations is solved with Gaussian elimination.
+// PLEASE NOTE: normal equations become ill-conditioned on large x values and high degrees,
+// so it is better to use small x values (e.g. bar indexes relative to the start of the window).
+func PolynomialRegression(x, y []float64, degree int) ([]float64, error) {
+	if len(x) != len(y) {
+		return nil, errors.New("stat4trading::PolynomialRegression: x and y should be the same length")
+	}
+
+	if degree < 1 {
+		return nil, errors.New("stat4trading::PolynomialRegression: degree should be at least 1")
+	}
+
+	if len(x) < degree+1 {
+		return nil, fmt.Errorf("stat4trading::PolynomialRegression: at least %d points are required to fit a polynomial of degree %d", degree+1, degree)
+	}
+
+	size := degree + 1
+
+	// Sums of powers of x: powerSums[k] = sum(x^k), k = 0..2*degree
+	powerSums := make([]float64, 2*degree+1)
+	// Right side of normal equations: rightSide[k] = sum(y * x^k), k = 0..degree
+	rightSide := make([]float64, size)
+
+	for i := 0; i < len(x); i++ {
+		xPower := 1.0
+
+		for k := 0; k < len(powerSums); k++ {
+			powerSums[k] += xPower
+
+			if k < size {
+				rightSide[k] += y[i] * xPower
+			}
+
+			xPower *= x[i]
+		}
+	}
+
+	// Augmented matrix of normal equations.
+	matrix := make([][]float64, size)
+
+	for row := 0; row < size; row++ {
+		matrix[row] = make([]float64, size+1)
+
+		for col := 0; col < size; col++ {
+			matrix[row][col] = powerSums[row+col]
+		}
+
+		matrix[row][size] = rightSide[row]
+	}
+
+	coefficients, err := solveLinearSystem(matrix)
+
+	if err != nil {
+		return nil, fmt.Errorf("stat4trading::PolynomialRegression: %w", err)
+	}
+
+	return coefficients, nil
+}
+
+// EvaluatePolynomial returns value of polynomial c[0] + c[1]*x + ... + c[n]*x^n at x (coefficients are from the lowest to the highest order).
+func EvaluatePolynomial(coeffs []float64, x float64) float64 {
+	// Horner's method
+	result := 0.0
+
+	for i := len(coeffs) - 1; i >= 0; i-- {
+		result = result*x + coeffs[i]
+	}
+
+	return result
+}
+
+// solveLinearSystem solves system of linear equations given by augmented N x (N+1) matrix
+// with Gaussian elimination with partial pivoting. Matrix is modified in place.
+func solveLinearSystem(matrix [][]float64) ([]float64, error) {
+	size := len(matrix)
+
+	for col := 0; col < size; col++ {
+		// Partial pivoting: swap current row with the row which has the largest absolute value in the current column.
+		pivotRow := col
+
+		for row := col + 1; row < size; row++ {
+			if math.Abs(matrix[row][col]) > math.Abs(matrix[pivotRow][col]) {
+				pivotRow = row
+			}
+		}
+
+		if isAlmostEqual(matrix[pivotRow][col], 0.0) {
+			return nil, errors.New("system of equations is singular, there are not enough distinct x values")
+		}
+
+		matrix[col], matrix[pivotRow] = matrix[pivotRow], matrix[col]
+
+		for row := col + 1; row < size; row++ {
+			factor := matrix[row][col] / matrix[col][col]
+
+			for k := col; k <= size; k++ {
+				matrix[row][k] -= factor * matrix[col][k]
+			}
+		}
+	}
+
+	// Back substitution
+	solution := make([]float64, size)
+
+	for row := size - 1; row >= 0; row-- {
+		sum := matrix[row][size]
+
+		for k := row + 1; k < size; k++ {
+			sum -= matrix[row][k] * solution[k]
+		}
+
+		solution[row] = sum / matrix[row][row]
+	}
+
+	return solution, nil
+}
+
+// AngleBetweenLines calculates the acute angle (in degrees) between two lines y = ax + b,
+// so the result is in range [0, 90]: 0 for parallel lines and 90 for perpendicular ones.
+func AngleBetweenLines(a, b LineDefinedByParameters) float64 {
+	// tan(angle) = |(k2 - k1) / (1 + k1*k2)|, atan2 handles the perpendicular case (1 + k1*k2 = 0) without division by zero.
+	angle := math.Atan2(math.Abs(b.ParamA-a.ParamA), math.Abs(1+a.ParamA*b.ParamA))
+
+	return angle * 180 / math.Pi
+}
+
+// NormalizedSlopeAngle calculates angle (in degrees, in range (-90, 90)) of the line as it is seen on a chart,
+// where priceRange is the visible price range (vertical axis) and indexRange is the visible number of bars (horizontal axis):
+// the slope is scaled by the aspect ratio indexRange/priceRange before taking the arctangent.
+// In contrast to the raw slope, the result doesn't depend on price scale, so it is comparable between instruments.
+func NormalizedSlopeAngle(line LineDefinedByParameters, priceRange, indexRange float64) (float64, error) {
+	if priceRange <= 0 || indexRange <= 0 {
+		return 0, errors.New("stat4trading::NormalizedSlopeAngle: price range and index range should be positive")
+	}
+
+	return math.Atan(line.ParamA*indexRange/priceRange) * 180 / math.Pi, nil
+}
+
+// ProjectPointOntoLine returns the foot of the perpendicular dropped from the point to the line y = ax + b,
+// i.e. the point of the line which is the closest to the given point.
+func ProjectPointOntoLine(point PointCoordinates, line LineDefinedByParameters) PointCoordinates {
+	a := line.ParamA
+	b := line.ParamB
+
+	x := (point.X + a*(point.Y-b)) / (1 + a*a)
+
+	return PointCoordinates{X: x, Y: EvaluateLineAt(line, x)}
+}
+
+// EvaluateLineAt returns value of the line y = ax + b at x (e.g. projected value of a trend line at a future index).
+func EvaluateLineAt(line LineDefinedByParameters, x float64) float64 {
+	return line.ParamA*x + line.ParamB
+}
+
+// EvaluateLineOver is a batch variant of EvaluateLineAt: it returns values of the line at every x of xs.
+func EvaluateLineOver(line LineDefinedByParameters, xs []float64) []float64 {
+	result := make([]float64, len(xs))
+
+	for i := 0; i < len(xs); i++ {
+		result[i] = EvaluateLineAt(line, xs[i])
+	}
+
+	return result
+}
+
+// SimplifyPolyline reduces number of vertices of the polyline by merging consecutive segments with almost the same slope:
+// a vertex is removed if slope of the segment coming into it (from the last kept vertex) differs from slope of the
+// segment going out of it by less than slopeTolerance. The first and the last points are always kept.
+// X coordinates of the points should be strictly increasing (as for price series, where X is time or bar index).
+func SimplifyPolyline(points []PointCoordinates, slopeTolerance float64) ([]PointCoordinates, error) {
+	if len(points) < 2 {
+		return nil, errors.New("stat4trading::SimplifyPolyline: at least 2 points are required")
+	}
+
+	if slopeTolerance < 0 {
+		return nil, errors.New("stat4trading::SimplifyPolyline: slope tolerance cannot be negative")
+	}
+
+	if !IsDataSortedASC(pointsXCoordinates(points)) {
+		return nil, errors.New("stat4trading::SimplifyPolyline: X coordinates of the points should be strictly increasing")
+	}
+
+	result := []PointCoordinates{points[0]}
+	lastKeptPoint := points[0]
+
+	for i := 1; i < len(points)-1; i++ {
+		incomingLine, err := FindEquationOfLineGivenByTwoPoints(LineDefinedByTwoPoints{PointA: lastKeptPoint, PointB: points[i]})
+
+		if err != nil {
+			return nil, fmt.Errorf("stat4trading::SimplifyPolyline: %w", err)
+		}
+
+		outgoingLine, err := FindEquationOfLineGivenByTwoPoints(LineDefinedByTwoPoints{PointA: points[i], PointB: points[i+1]})
+
+		if err != nil {
+			return nil, fmt.Errorf("stat4trading::SimplifyPolyline: %w", err)
+		}
+
+		if math.Abs(incomingLine.ParamA-outgoingLine.ParamA) < slopeTolerance {
+			continue
+		}
+
+		result = append(result, points[i])
+		lastKeptPoint = points[i]
+	}
+
+	return append(result, points[len(points)-1]), nil
+}
+
+// DouglasPeucker simplifies the polyline with Ramer-Douglas-Peucker algorithm: starting from the chord between the first
+// and the last points, the point which is the most distant (perpendicularly) from the chord is kept if its distance exceeds epsilon,
+// and both halves are processed recursively. The result is a compact set of significant pivot points of a noisy curve.
+// X coordinates of the points should be strictly increasing (as for price series, where X is time or bar index).
+func DouglasPeucker(points []PointCoordinates, epsilon float64) ([]PointCoordinates, error) {
+	if epsilon <= 0 {
+		return nil, errors.New("stat4trading::DouglasPeucker: epsilon should be positive")
+	}
+
+	if len(points) < 2 {
+		return nil, errors.New("stat4trading::DouglasPeucker: at least 2 points are required")
+	}
+
+	if !IsDataSortedASC(pointsXCoordinates(points)) {
+		return nil, errors.New("stat4trading::DouglasPeucker: X coordinates of the points should be strictly increasing")
+	}
+
+	keep := make([]bool, len(points))
+	keep[0] = true
+	keep[len(points)-1] = true
+
+	// Stack of [start, end] index pairs of segments which are still to be processed (instead of recursion).
+	stack := [][2]int{{0, len(points) - 1}}
+
+	for len(stack) > 0 {
+		segment := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		start, end := segment[0], segment[1]
+
+		if end-start < 2 {
+			continue
+		}
+
+		chord, err := FindEquationOfLineGivenByTwoPoints(LineDefinedByTwoPoints{PointA: points[start], PointB: points[end]})
+
+		if err != nil {
+			return nil, fmt.Errorf("stat4trading::DouglasPeucker: %w", err)
+		}
+
+		maxDistance := 0.0
+		maxDistanceIndex := start
+
+		for i := start + 1; i < end; i++ {
+			distance := distanceFromPointToLine(points[i], chord)
+
+			if distance > maxDistance {
+				maxDistance = distance
+				maxDistanceIndex = i
+			}
+		}
+
+		if maxDistance > epsilon {
+			keep[maxDistanceIndex] = true
+			stack = append(stack, [2]int{start, maxDistanceIndex}, [2]int{maxDistanceIndex, end})
+		}
+	}
+
+	result := make([]PointCoordinates, 0)
+
+	for i := 0; i < len(points); i++ {
+		if keep[i] {
+			result = append(result, points[i])
+		}
+	}
+
+	return result, nil
+}
+
+// TrendLineDirection defines which side of the pivots FitTrendLine fits the line to.
+type TrendLineDirection string
+
+const (
+	// TrendLineLower is a support line: it goes under all the pivots, touching the lowest one(s).
+	TrendLineLower TrendLineDirection = "lower"
+	// TrendLineUpper is a resistance line: it goes above all the pivots, touching the highest one(s).
+	TrendLineUpper TrendLineDirection = "upper"
+)
+
+// FitTrendLine fits a support (lower) or resistance (upper) trend line to the pivot points (e.g. troughs or peaks found by FindPeaksAndTroughs).
+// The slope is estimated with robust TheilSen estimator, so a single outlier pivot doesn't dominate it,
+// then the line is shifted to touch the extreme pivot, so all the pivots are on the same side of the line.
+func FitTrendLine(points []PointCoordinates, direction TrendLineDirection) (LineDefinedByParameters, error) {
+	if direction != TrendLineLower && direction != TrendLineUpper {
+		return LineDefinedByParameters{}, fmt.Errorf("stat4trading::FitTrendLine: unknown trend line direction %q", direction)
+	}
+
+	y := make([]float64, len(points))
+
+	for i := 0; i < len(points); i++ {
+		y[i] = points[i].Y
+	}
+
+	line, err := TheilSen(pointsXCoordinates(points), y)
+
+	if err != nil {
+		return LineDefinedByParameters{}, fmt.Errorf("stat4trading::FitTrendLine: %w", err)
+	}
+
+	// Intercepts of lines with the fitted slope, going through every pivot.
+	intercepts := make([]float64, len(points))
+
+	for i := 0; i < len(points); i++ {
+		intercepts[i] = points[i].Y - line.ParamA*points[i].X
+	}
+
+	if direction == TrendLineLower {
+		line.ParamB, _, _ = FindMin(intercepts)
+	} else {
+		line.ParamB, _, _ = FindMax(intercepts)
+	}
+
+	return line, nil
+}
+
+// GannAngles builds Gann fan: lines of the standard Gann angles emanating from the pivot, in slope-intercept form.
+// priceUnitsPerBar defines the scale: how many price units correspond to one bar on the 1x1 (45 degrees) line.
+// Keys are in "TIMExPRICE" notation: "1x2" rises 2 price units per 1 bar (steeper than 1x1), "2x1" rises 1 price unit per 2 bars.
+// Standard set is returned: 1x8, 1x4, 1x3, 1x2, 1x1, 2x1, 3x1, 4x1, 8x1.
+// All lines are ascending (for a fan from the pivot low); for a fan from the pivot high mirror the slopes (ParamA) around the pivot.
+func GannAngles(pivot PointCoordinates, priceUnitsPerBar float64) (map[string]LineDefinedByParameters, error) {
+	if priceUnitsPerBar <= 0 {
+		return nil, errors.New("stat4trading::GannAngles: price units per bar should be positive")
+	}
+
+	ratios := []struct {
+		name  string
+		bars  float64
+		price float64
+	}{
+		{"1x8", 1, 8}, {"1x4", 1, 4}, {"1x3", 1, 3}, {"1x2", 1, 2}, {"1x1", 1, 1},
+		{"2x1", 2, 1}, {"3x1", 3, 1}, {"4x1", 4, 1}, {"8x1", 8, 1},
+	}
+
+	result := make(map[string]LineDefinedByParameters, len(ratios))
+
+	for _, ratio := range ratios {
+		slope := ratio.price / ratio.bars * priceUnitsPerBar
+
+		result[ratio.name] = LineDefinedByParameters{
+			ParamA: slope,
+			ParamB: pivot.Y - slope*pivot.X,
+		}
+	}
+
+	return result, nil
+}
+
+// FibonacciRetracements calculates price levels for the standard Fibonacci retracement ratios
+// (0, 0.236, 0.382, 0.5, 0.618, 0.786, 1.0) between swing high and swing low.
+// The result maps ratio to price level. Level is measured down from the high: level = high - ratio*(high-low),
+// so ratio 0 is the high itself, and ratio 1 is the low.
+// If high < low, they are swapped.
+func FibonacciRetracements(high, low float64) map[float64]float64 {
+	return FibonacciRetracementsCustom(high, low, []float64{0, 0.236, 0.382, 0.5, 0.618, 0.786, 1.0})
+}
+
+// FibonacciRetracementsCustom is the same as FibonacciRetracements, but for custom ratios
+// (for example, extension levels like 1.618 can be used).
+func FibonacciRetracementsCustom(high, low float64, ratios []float64) map[float64]float64 {
+	if high < low {
+		high, low = low, high
+	}
+
+	swingRange := high - low
+	result := make(map[float64]float64, len(ratios))
+
+	for _, ratio := range ratios {
+		result[ratio] = high - ratio*swingRange
+	}
+
+	return result
+}
+
+// PivotMethod defines the formula used by PivotPoints.
+type PivotMethod string
+
+const (
+	PivotClassic   PivotMethod = "classic"
+	PivotFibonacci PivotMethod = "fibonacci"
+)
+
+// PivotPoints calculates pivot point P with resistance levels [R1, R2, R3] and support levels [S1, S2, S3]
+// from the previous period (e.g. previous day) high, low and close prices.
+// Supported methods:
+//   - classic:   R1 = 2P - L, S1 = 2P - H, R2 = P + (H - L), S2 = P - (H - L), R3 = H + 2(P - L), S3 = L - 2(H - P);
+//   - fibonacci: R/S = P ± {0.382, 0.618, 1.0} * (H - L).
+//
+// In both methods P = (H + L + C) / 3.
+func PivotPoints(prevHigh, prevLow, prevClose float64, method PivotMethod) (pivot float64, resistances, supports []float64, err error) {
+	if prevHigh < prevLow {
+		return 0, nil, nil, errors.New("stat4trading::PivotPoints: previous high cannot be less than previous low")
+	}
+
+	pivot = (prevHigh + prevLow + prevClose) / 3
+	priceRange := prevHigh - prevLow
+
+	switch method {
+	case PivotClassic:
+		resistances = []float64{
+			2*pivot - prevLow,
+			pivot + priceRange,
+			prevHigh + 2*(pivot-prevLow),
+		}
+		supports = []float64{
+			2*pivot - prevHigh,
+			pivot - priceRange,
+			prevLow - 2*(prevHigh-pivot),
+		}
+	case PivotFibonacci:
+		resistances = []float64{
+			pivot + 0.382*priceRange,
+			pivot + 0.618*priceRange,
+			pivot + 1.0*priceRange,
+		}
+		supports = []float64{
+			pivot - 0.382*priceRange,
+			pivot - 0.618*priceRange,
+			pivot - 1.0*priceRange,
+		}
+	default:
+		return 0, nil, nil, fmt.Errorf("stat4trading::PivotPoints: unknown pivot method %q", method)
+	}
+
+	return pivot, resistances, supports, nil
+}
+
+// SmoothBy3Points performs 3-point moving average smoothing (passesNum times), preserving data length.
+// The first and the last points are calculated with special extrapolating formulas (BoundaryFormula).
+// If there are not enough points (less than 3), or passesNum <= 0, inData is returned as is.
+// PLEASE NOTE: for backward compatibility inData is smoothed IN PLACE (and the returned slice holds the same values),
+// use Smooth to keep input data set unmodified.
+func SmoothBy3Points(inData []float64, passesNum int) []float64 {
+	if passesNum <= 0 || len(inData) < 3 {
+		return inData
+	}
+
+	// Smooth can't fail with a valid kernel size and boundary mode.
+	smoothedData, _ := Smooth(inData, 3, SmoothOptions{Passes: passesNum, Boundary: BoundaryFormula})
+	copy(inData, smoothedData)
+
+	return smoothedData
+}
+
+// SmoothBy5Points performs 5-point moving average smoothing (passesNum times), preserving data length.
+// Two first and two last points are calculated with special extrapolating formulas (BoundaryFormula).
+// If there are not enough points (less than 5), or passesNum <= 0, inData is returned as is.
+// PLEASE NOTE: for backward compatibility inData is smoothed IN PLACE (and the returned slice holds the same values),
+// use Smooth to keep input data set unmodified.
+func SmoothBy5Points(inData []float64, passesNum int) []float64 {
+	if passesNum <= 0 || len(inData) < 5 {
+		return inData
+	}
+
+	// Smooth can't fail with a valid kernel size and boundary mode.
+	smoothedData, _ := Smooth(inData, 5, SmoothOptions{Passes: passesNum, Boundary: BoundaryFormula})
+	copy(inData, smoothedData)
+
+	return smoothedData
+}
+
+// SmoothAdaptive performs adaptive smoothing:
+// 5-point smoothing in priority,
+// but if there are not enough points for 5-point smoothing, it does 3-point smoothing,
+// and if there are not enough points even for 3-p smoothing, it just returns the input
+func SmoothAdaptive(inData []float64, passesNum int) []float64 {
+	if len(inData) < 3 {
+		return inData
+	}
+
+	if len(inData) < 5 {
+		return SmoothBy3Points(inData, passesNum)
+	}
+
+	return SmoothBy5Points(inData, passesNum)
+}
+
+// SmoothWithBands smooths the data set with SmoothAdaptive and builds bands at ±1 rolling standard deviation
+// of residuals (original - smoothed) around the smoothed data. Width of the bands shows how much the smoother discards.
+// Rolling standard deviation is calculated over windows of width windowWidth (this parameter is required in addition
+// to the ones of SmoothAdaptive). All three output data sets have the same length as inData: smoothed is complete,
+// while the first windowWidth-1 values of upper and lower are NaN, because there is no full window for them yet.
+// Input data set is not modified.
+func SmoothWithBands(inData []float64, passesNum int, windowWidth int) (smoothed, upper, lower []float64, err error) {
+	if windowWidth < 1 {
+		return nil, nil, nil, errors.New("stat4trading::SmoothWithBands: window width should be positive")
+	}
+
+	if CalculateOutputDataLengthAfterMA(len(inData), windowWidth) <= 0 {
+		return nil, nil, nil, errors.New("stat4trading::SmoothWithBands: not enough data to calculate bands of specified window width, increase data set or reduce window width")
+	}
+
+	// SmoothAdaptive smooths data in place, so a copy is smoothed to keep original data for residuals.
+	smoothed = make([]float64, len(inData))
+	copy(smoothed, inData)
+	smoothed = SmoothAdaptive(smoothed, passesNum)
+
+	residuals, err := Subtract(inData, smoothed)
+
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("stat4trading::SmoothWithBands: %w", err)
+	}
+
+	residualsStdDev := calculateRollingStdDev(residuals, windowWidth)
+	upper = make([]float64, len(inData))
+	lower = make([]float64, len(inData))
+
+	for i := 0; i < len(inData); i++ {
+		if i < windowWidth-1 {
+			upper[i] = math.NaN()
+			lower[i] = math.NaN()
+			continue
+		}
+
+		upper[i] = smoothed[i] + residualsStdDev[i-windowWidth+1]
+		lower[i] = smoothed[i] - residualsStdDev[i-windowWidth+1]
+	}
+
+	return smoothed, upper, lower, nil
+}
+
+// BoundaryMode defines how the first and the last points (where the smoothing window doesn't fit into the data set)
+// are handled by SmoothBy3PointsWithBoundary and SmoothBy5PointsWithBoundary. The mode is applied to both ends symmetrically.
+type BoundaryMode int
+
+const (
+	// BoundaryFormula uses special extrapolating formulas for the edge points (the same as SmoothBy3Points and SmoothBy5Points do).
+	BoundaryFormula BoundaryMode = iota
+	// BoundaryReflect mirrors the data set around the edge point: [... x2, x1, | x0, x1, x2 ...].
+	BoundaryReflect
+	// BoundaryNearest replicates the edge point: [... x0, x0, | x0, x1, x2 ...].
+	BoundaryNearest
+	// BoundaryOriginal leaves the edge points unchanged.
+	BoundaryOriginal
+)
+
+// SmoothOptions configures Smooth. Zero value means no smoothing (0 passes) with BoundaryFormula.
+type SmoothOptions struct {
+	// Passes is the number of smoothing passes, if it is <= 0 a copy of the input is returned.
+	Passes int
+	// KeepFirst keeps the very first value equal to the original one, regardless of Boundary.
+	KeepFirst bool
+	// KeepLast keeps the very last value equal to the original one, regardless of Boundary.
+	KeepLast bool
+	// Boundary defines how the edge points (where the smoothing window doesn't fit into the data set) are handled.
+	Boundary BoundaryMode
+}
+
+// Smooth performs moving average smoothing with the kernel of kernelSize points (3 or 5), preserving data length.
+// It is the common entry point for SmoothBy3Points, SmoothBy5Points and their variants with boundary mode.
+// If there are not enough points for the kernel, or opts.Passes <= 0, a copy of the input is returned.
+// Input data set is not modified.
+func Smooth(inData []float64, kernelSize int, opts SmoothOptions) ([]float64, error) {
+	if kernelSize != 3 && kernelSize != 5 {
+		return nil, fmt.Errorf("stat4trading::Smooth: unsupported kernel size %d, only 3 and 5 are supported", kernelSize)
+	}
+
+	result, err := smoothWithBoundary(inData, kernelSize/2, opts.Passes, opts.Boundary, opts.KeepFirst, opts.KeepLast)
+
+	if err != nil {
+		return nil, fmt.Errorf("stat4trading::Smooth: %w", err)
+	}
+
+	return result, nil
+}
+
+// SmoothBy3PointsWithBoundary is the same as SmoothBy3Points, but edge points are handled according to the boundary mode.
+// In contrast to SmoothBy3Points, input data set is not modified.
+// Additionally, the very first and/or the very last value can be kept equal to the original one
+// (with keepFirstValueOriginal / keepLastValueOriginal), regardless of the boundary mode.
+func SmoothBy3PointsWithBoundary(inData []float64, passesNum int, boundary BoundaryMode, keepFirstValueOriginal, keepLastValueOriginal bool) ([]float64, error) {
+	result, err := Smooth(inData, 3, SmoothOptions{
+		Passes:    passesNum,
+		KeepFirst: keepFirstValueOriginal,
+		KeepLast:  keepLastValueOriginal,
+		Boundary:  boundary,
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("stat4trading::SmoothBy3PointsWithBoundary: %w", err)
+	}
+
+	return result, nil
+}
+
+// SmoothBy5PointsWithBoundary is the same as SmoothBy5Points, but edge points are handled according to the boundary mode.
+// In contrast to SmoothBy5Points, input data set is not modified.
+// Additionally, the very first and/or the very last value can be kept equal to the original one
+// (with keepFirstValueOriginal / keepLastValueOriginal), regardless of the boundary mode.
+func SmoothBy5PointsWithBoundary(inData []float64, passesNum int, boundary BoundaryMode, keepFirstValueOriginal, keepLastValueOriginal bool) ([]float64, error) {
+	result, err := Smooth(inData, 5, SmoothOptions{
+		Passes:    passesNum,
+		KeepFirst: keepFirstValueOriginal,
+		KeepLast:  keepLastValueOriginal,
+		Boundary:  boundary,
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("stat4trading::SmoothBy5PointsWithBoundary: %w", err)
+	}
+
+	return result, nil
+}
+
+// smoothWithBoundary performs moving average smoothing with window of 2*halfWidth+1 points, preserving data length.
+// If there are not enough points for the window, or passesNum <= 0, a copy of input is returned.
+func smoothWithBoundary(inData []float64, halfWidth int, passesNum int, boundary BoundaryMode, keepFirstValueOriginal, keepLastValueOriginal bool) ([]float64, error) {
+	if boundary < BoundaryFormula || boundary > BoundaryOriginal {
+		return nil, fmt.Errorf("unknown boundary mode %d", int(boundary))
+	}
+
+	windowWidth := 2*halfWidth + 1
+	current := make([]float64, len(inData))
+	copy(current, inData)
+
+	if passesNum <= 0 || len(inData) < windowWidth {
+		return current, nil
+	}
+
+	lastIndex := len(inData) - 1
+	smoothedData := make([]float64, len(inData))
+
+	for p := 0; p < passesNum; p++ {
+		for i := 0; i <= lastIndex; i++ {
+			isEdgePoint := i < halfWidth || i > lastIndex-halfWidth
+
+			if isEdgePoint && boundary == BoundaryOriginal {
+				smoothedData[i] = current[i]
+				continue
+			}
+
+			if isEdgePoint && boundary == BoundaryFormula {
+				// Edge points are calculated below, all at once.
+				continue
+			}
+
+			sum := 0.0
+
+			for j := i - halfWidth; j <= i+halfWidth; j++ {
+				sum += current[resolveBoundaryIndex(j, lastIndex, boundary)]
+			}
+
+			smoothedData[i] = sum / float64(windowWidth)
+		}
+
+		if boundary == BoundaryFormula {
+			applyBoundaryFormula(current, smoothedData, halfWidth)
+		}
+
+		if keepFirstValueOriginal {
+			smoothedData[0] = inData[0]
+		}
+
+		if keepLastValueOriginal {
+			smoothedData[lastIndex] = inData[lastIndex]
+		}
+
+		copy(current, smoothedData)
+	}
+
+	return current, nil
+}
+
+// resolveBoundaryIndex maps index which is out of [0, lastIndex] range to the real index, according to the boundary mode.
+// Indexes inside the range are returned as is.
+func resolveBoundaryIndex(index int, lastIndex int, boundary BoundaryMode) int {
+	if index >= 0 && index <= lastIndex {
+		return index
+	}
+
+	if boundary == BoundaryReflect {
+		if index < 0 {
+			return -index
+		}
+
+		return 2*lastIndex - index
+	}
+
+	// BoundaryNearest
+	if index < 0 {
+		return 0
+	}
+
+	return lastIndex
+}
+
+// applyBoundaryFormula calculates edge points of smoothed data with the same formulas as SmoothBy3Points and SmoothBy5Points use.
+func applyBoundaryFormula(inData []float64, smoothedData []float64, halfWidth int) {
+	lastIndex := len(inData) - 1
+
+	if halfWidth == 1 {
+		smoothedData[0] = (5*inData[0] + 2*inData[1] - inData[2]) / 6
+		smoothedData[lastIndex] = (-inData[lastIndex-2] + 2*inData[lastIndex-1] + 5*inData[lastIndex]) / 6
+
+		return
+	}
+
+	smoothedData[0] = (3*inData[0] + 2*inData[1] + inData[2] - inData[4]) / 5
+	smoothedData[1] = (4*inData[0] + 3*inData[1] + 2*inData[2] + inData[3]) / 10
+	smoothedData[lastIndex-1] = (inData[lastIndex-3] + 2*inData[lastIndex-2] + 3*inData[lastIndex-1] + 4*inData[lastIndex]) / 10
+	smoothedData[lastIndex] = (-inData[lastIndex-4] + inData[lastIndex-2] + 2*inData[lastIndex-1] + 3*inData[lastIndex]) / 5
+}
+
+// GaussianSmooth convolves the data set with a normalized Gaussian kernel of width windowWidth and standard deviation sigma.
+// windowWidth should be odd, so the kernel is symmetric around its central point.
+// Only full windows are used (no padding at the edges), so output data length is the same as after SMA:
+// len(inputData) - windowWidth + 1, and output[i] corresponds to inputData[i + (windowWidth-1)/2].
+func GaussianSmooth(inputData []float64, sigma float64, windowWidth int) ([]float64, error) {
+	if windowWidth < 1 || windowWidth%2 == 0 {
+		return nil, errors.New("stat4trading::GaussianSmooth: window width should be positive odd number")
+	}
+
+	if sigma <= 0 {
+		return nil, errors.New("stat4trading::GaussianSmooth: sigma should be positive")
+	}
+
+	outputDataLength := CalculateOutputDataLengthAfterMA(len(inputData), windowWidth)
+
+	if outputDataLength <= 0 {
+		return nil, errors.New("stat4trading::GaussianSmooth: not enough data to smooth with specified window width, increase data set or reduce window width")
+	}
+
+	halfWidth := windowWidth / 2
+	kernel := make([]float64, windowWidth)
+
+	for i := 0; i < windowWidth; i++ {
+		distance := float64(i - halfWidth)
+		kernel[i] = math.Exp(-distance * distance / (2 * sigma * sigma))
+	}
+
+	// CustomWMA normalizes by the sum of weights, so the kernel doesn't need to be normalized here.
+	result, err := CustomWMA(inputData, kernel, outputDataLength)
+
+	if err != nil {
+		return nil, fmt.Errorf("stat4trading::GaussianSmooth: %w", err)
+	}
+
+	return result, nil
+}
+
+// HampelFilter removes isolated spikes: for every point it calculates median and median absolute deviation (MAD)
+// of the window of width windowWidth centered on the point, and replaces the point with the median
+// if it deviates from the median by more than nSigmas * 1.4826 * MAD (1.4826 scales MAD to standard deviation for normal distribution).
+// windowWidth should be odd. The first and the last windowWidth/2 points don't have full window, so they are left unchanged.
+// Output data length is the same as input, input data set is not modified.
+func HampelFilter(data []float64, windowWidth int, nSigmas float64) ([]float64, error) {
+	if windowWidth < 3 || windowWidth%2 == 0 {
+		return nil, errors.New("stat4trading::HampelFilter: window width should be odd number, at least 3")
+	}
+
+	if nSigmas < 0 {
+		return nil, errors.New("stat4trading::HampelFilter: number of sigmas cannot be negative")
+	}
+
+	if len(data) < windowWidth {
+		return nil, errors.New("stat4trading::HampelFilter: not enough data to filter with specified window width, increase data set or reduce window width")
+	}
+
+	const madScaleFactor = 1.4826
+
+	halfWidth := windowWidth / 2
+	result := make([]float64, len(data))
+	copy(result, data)
+
+	for i := halfWidth; i < len(data)-halfWidth; i++ {
+		window := data[i-halfWidth : i+halfWidth+1]
+		windowMedian := median(window)
+		mad, err := MedianAbsoluteDeviation(window)
+
+		if err != nil {
+			return nil, fmt.Errorf("stat4trading::HampelFilter: %w", err)
+		}
+
+		if math.Abs(data[i]-windowMedian) > nSigmas*madScaleFactor*mad {
+			result[i] = windowMedian
+		}
+	}
+
+	return result, nil
+}
+
+// FindMax finds maximum value and its index in the data set.
+// NaN values are skipped. If data set consists of NaN values only, an error is returned.
+func FindMax[N Numeric](data []N) (N, int, error) {
+	if len(data) == 0 {
+		return 0, 0, errors.New("stat4trading::FindMax: Input data set cannot be empty!")
+	}
+
+	index := findFirstNotNaNIndex(data)
+
+	if index < 0 {
+		return 0, 0, errors.New("stat4trading::FindMax: Input data set consists of NaN values only!")
+	}
+
+	maxValue := data[index]
+
+	for i := index + 1; i < len(data); i++ {
+		if data[i] > maxValue {
+			maxValue = data[i]
+			index = i
+		}
+	}
+
+	return maxValue, index, nil
+}
+
+// FindMin finds minimum value and its index in the data set.
+// NaN values are skipped. If data set consists of NaN values only, an error is returned.
+func FindMin[N Numeric](data []N) (N, int, error) {
+	if len(data) == 0 {
+		return 0, 0, errors.New("stat4trading::FindMin: Input data set cannot be empty!")
+	}
+
+	index := findFirstNotNaNIndex(data)
+
+	if index < 0 {
+		return 0, 0, errors.New("stat4trading::FindMin: Input data set consists of NaN values only!")
+	}
+
+	minValue := data[index]
+
+	for i := index + 1; i < len(data); i++ {
+		if data[i] < minValue {
+			minValue = data[i]
+			index = i
+		}
+	}
+
+	return minValue, index, nil
+}
+
+// FindMinMax finds both minimum and maximum values (and their indexes) in a single pass through the data set.
+// It is cheaper than calling FindMin and FindMax separately on long data sets.
+// NaN values are skipped. If data set consists of NaN values only, an error is returned.
+func FindMinMax[N Numeric](data []N) (minValue N, minIndex int, maxValue N, maxIndex int, err error) {
+	if len(data) == 0 {
+		return 0, 0, 0, 0, errors.New("stat4trading::FindMinMax: Input data set cannot be empty!")
+	}
+
+	startIndex := findFirstNotNaNIndex(data)
+
+	if startIndex < 0 {
+		return 0, 0, 0, 0, errors.New("stat4trading::FindMinMax: Input data set consists of NaN values only!")
+	}
+
+	minValue, minIndex = data[startIndex], startIndex
+	maxValue, maxIndex = data[startIndex], startIndex
+
+	for i := startIndex + 1; i < len(data); i++ {
+		if data[i] < minValue {
+			minValue = data[i]
+			minIndex = i
+		}
+
+		if data[i] > maxValue {
+			maxValue = data[i]
+			maxIndex = i
+		}
+	}
+
+	return minValue, minIndex, maxValue, maxIndex, nil
+}
+
+// FindPeaksAndTroughs finds indexes of all local maxima (peaks) and local minima (troughs) of the data set.
+// A peak is a point strictly greater than both its neighbours, a trough is a point strictly less than both its neighbours,
+// so the first and the last points, as well as flat tops/bottoms (plateaus), are never reported.
+// minProminence filters out noise: peak (trough) is reported only if its prominence is at least minProminence.
+// Prominence of a peak is how much it stands out from the surrounding data: the height of the peak above the highest of two
+// minimums, found on the left and on the right side of the peak before reaching a higher point (or the data set boundary).
+// Prominence of a trough is defined the same way upside-down. Use minProminence = 0 to get all the local extrema.
+func FindPeaksAndTroughs(data []float64, minProminence float64) (peaks []int, troughs []int, err error) {
+	if minProminence < 0 {
+		return nil, nil, errors.New("stat4trading::FindPeaksAndTroughs: minimum prominence cannot be negative")
+	}
+
+	peaks = make([]int, 0)
+	troughs = make([]int, 0)
+
+	for i := 1; i < len(data)-1; i++ {
+		if data[i] > data[i-1] && data[i] > data[i+1] {
+			if calculateProminence(data, i, func(a, b float64) bool { return a > b }) >= minProminence {
+				peaks = append(peaks, i)
+			}
+		} else if data[i] < data[i-1] && data[i] < data[i+1] {
+			if calculateProminence(data, i, func(a, b float64) bool { return a < b }) >= minProminence {
+				troughs = append(troughs, i)
+			}
+		}
+	}
+
+	return peaks, troughs, nil
+}
+
+// calculateProminence calculates prominence of the extremum at index.
+// isBeyond(a, b) should return true if a is "beyond" b: greater for peaks and less for troughs.
+func calculateProminence(data []float64, index int, isBeyond func(a, b float64) bool) float64 {
+	// Base on each side is the most distant (from the extremum) value, found before reaching a point beyond the extremum.
+	leftBase := data[index]
+
+	for j := index - 1; j >= 0 && !isBeyond(data[j], data[index]); j-- {
+		if isBeyond(leftBase, data[j]) {
+			leftBase = data[j]
+		}
+	}
+
+	rightBase := data[index]
+
+	for j := index + 1; j < len(data) && !isBeyond(data[j], data[index]); j++ {
+		if isBeyond(rightBase, data[j]) {
+			rightBase = data[j]
+		}
+	}
+
+	// The reference level is the base which is closer to the extremum.
+	referenceLevel := leftBase
+
+	if isBeyond(rightBase, leftBase) {
+		referenceLevel = rightBase
+	}
+
+	return math.Abs(data[index] - referenceLevel)
+}
+
+// DivergenceType is the type of divergence between price and indicator.
+type DivergenceType string
+
+const (
+	BullishDivergence DivergenceType = "BULLISH"
+	BearishDivergence DivergenceType = "BEARISH"
+)
+
+// Divergence describes divergence between two consecutive price extrema at StartIdx and EndIdx.
+type Divergence struct {
+	StartIdx int
+	EndIdx   int
+	Type     DivergenceType
+}
+
+// FindDivergences finds divergences between price and indicator (e.g. RSI):
+//   - bullish divergence: price makes a lower low (trough), while indicator at the same points makes a higher low;
+//   - bearish divergence: price makes a higher high (peak), while indicator at the same points makes a lower high.
+//
+// Extrema are searched in price with FindPeaksAndTroughs, and only consecutive peaks (troughs) are compared.
+// Both data sets should be the same length and aligned by index (use AlignTrailing if indicator is shorter than price).
+// Result is sorted by EndIdx.
+func FindDivergences(price, indicator []float64) ([]Divergence, error) {
+	if len(price) != len(indicator) {
+		return nil, errors.New("stat4trading::FindDivergences: both input data sets should be the same length")
+	}
+
+	peaks, troughs, err := FindPeaksAndTroughs(price, 0)
+
+	if err != nil {
+		return nil, fmt.Errorf("stat4trading::FindDivergences: %w", err)
+	}
+
+	result := make([]Divergence, 0)
+
+	for i := 1; i < len(troughs); i++ {
+		start, end := troughs[i-1], troughs[i]
+
+		if price[end] < price[start] && indicator[end] > indicator[start] {
+			result = append(result, Divergence{StartIdx: start, EndIdx: end, Type: BullishDivergence})
+		}
+	}
+
+	for i := 1; i < len(peaks); i++ {
+		start, end := peaks[i-1], peaks[i]
+
+		if price[end] > price[start] && indicator[end] < indicator[start] {
+			result = append(result, Divergence{StartIdx: start, EndIdx: end, Type: BearishDivergence})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].EndIdx < result[j].EndIdx
+	})
+
+	return result, nil
+}
+
+// WilliamsFractals finds indexes of Bill Williams fractals:
+// up fractal is a bar whose high is strictly higher than highs of two bars on each side,
+// down fractal is a bar whose low is strictly lower than lows of two bars on each side.
+// The first two and the last two bars can't be fractals, because they don't have two neighbours on both sides.
+func WilliamsFractals(high, low []float64) (upFractals, downFractals []int, err error) {
+	if len(high) != len(low) {
+		return nil, nil, errors.New("stat4trading::WilliamsFractals: high and low data sets should be the same length")
+	}
+
+	upFractals = make([]int, 0)
+	downFractals = make([]int, 0)
+
+	for i := 2; i < len(high)-2; i++ {
+		if high[i] > high[i-2] && high[i] > high[i-1] && high[i] > high[i+1] && high[i] > high[i+2] {
+			upFractals = append(upFractals, i)
+		}
+
+		if low[i] < low[i-2] && low[i] < low[i-1] && low[i] < low[i+1] && low[i] < low[i+2] {
+			downFractals = append(downFractals, i)
+		}
+	}
+
+	return upFractals, downFractals, nil
+}
+
+// TopK returns k largest values of the data set sorted in descending order, without sorting the whole data set:
+// it keeps a min-heap of k candidates, so complexity is O(n log k). NaN values are skipped.
+func TopK[N Numeric](data []N, k int) ([]N, error) {
+	result, err := selectK(data, k, func(a, b N) bool { return a < b })
+
+	if err != nil {
+		return nil, fmt.Errorf("stat4trading::TopK: %w", err)
+	}
+
+	return result, nil
+}
+
+// BottomK returns k smallest values of the data set sorted in ascending order, without sorting the whole data set:
+// it keeps a max-heap of k candidates, so complexity is O(n log k). NaN values are skipped.
+func BottomK[N Numeric](data []N, k int) ([]N, error) {
+	result, err := selectK(data, k, func(a, b N) bool { return a > b })
+
+	if err != nil {
+		return nil, fmt.Errorf("stat4trading::BottomK: %w", err)
+	}
+
+	return result, nil
+}
+
+// selectK selects k "best" values with the heap where the worst candidate is on top.
+// isWorse(a, b) should return true if a is worse candidate than b.
+// Result is sorted from the best to the worst.
+func selectK[N Numeric](data []N, k int, isWorse func(a, b N) bool) ([]N, error) {
+	if k <= 0 {
+		return nil, errors.New("k should be positive")
+	}
+
+	candidates := &candidatesHeap[N]{values: make([]N, 0, k), isWorse: isWorse}
+	notNaNCount := 0
+
+	for i := 0; i < len(data); i++ {
+		if math.IsNaN(float64(data[i])) {
+			continue
+		}
+
+		notNaNCount++
+
+		if candidates.Len() < k {
+			heap.Push(candidates, data[i])
+		} else if isWorse(candidates.values[0], data[i]) {
+			candidates.values[0] = data[i]
+			heap.Fix(candidates, 0)
+		}
+	}
+
+	if k > notNaNCount {
+		return nil, fmt.Errorf("k = %d is greater than the number of values in the data set (%d)", k, notNaNCount)
+	}
+
+	// Popping from the heap gives candidates from the worst to the best.
+	result := make([]N, k)
+
+	for i := k - 1; i >= 0; i-- {
+		result[i] = heap.Pop(candidates).(N)
+	}
+
+	return result, nil
+}
+
+// candidatesHeap implements heap.Interface, the worst candidate is on top.
+type candidatesHeap[N Numeric] struct {
+	values  []N
+	isWorse func(a, b N) bool
+}
+
+func (h *candidatesHeap[N]) Len() int           { return len(h.values) }
+func (h *candidatesHeap[N]) Less(i, j int) bool { return h.isWorse(h.values[i], h.values[j]) }
+func (h *candidatesHeap[N]) Swap(i, j int)      { h.values[i], h.values[j] = h.values[j], h.values[i] }
+func (h *candidatesHeap[N]) Push(x any)         { h.values = append(h.values, x.(N)) }
+
+func (h *candidatesHeap[N]) Pop() any {
+	last := h.values[len(h.values)-1]
+	h.values = h.values[:len(h.values)-1]
+
+	return last
+}
+
+// Clamp returns a new data set where every value is constrained to the range [minValue, maxValue].
+// If minValue > maxValue, they are swapped, so the range is always valid.
+// NaN values (if any) are left as is.
+func Clamp[N Numeric](data []N, minValue, maxValue N) []N {
+	if minValue > maxValue {
+		minValue, maxValue = maxValue, minValue
+	}
+
+	result := make([]N, len(data))
+
+	for i := 0; i < len(data); i++ {
+		switch {
+		case data[i] < minValue:
+			result[i] = minValue
+		case data[i] > maxValue:
+			result[i] = maxValue
+		default:
+			result[i] = data[i]
+		}
+	}
+
+	return result
+}
+
+// Reverse returns a new data set with the reversed order of values (input data set is not modified).
+// It is useful when data comes newest-first, while moving averages and other functions of the package assume chronological order.
+func Reverse[N Numeric](data []N) []N {
+	result := make([]N, len(data))
+
+	for i := 0; i < len(data); i++ {
+		result[len(data)-1-i] = data[i]
+	}
+
+	return result
+}
+
+// Shift shifts the data set forward (positive periods) or backward (negative periods) by the given number of periods,
+// filling vacated positions with fill (e.g. math.NaN()). Output data length is the same as input.
+// For example, Shift([1, 2, 3, 4], 1, NaN) = [NaN, 1, 2, 3] (lag), and Shift([1, 2, 3, 4], -1, NaN) = [2, 3, 4, NaN] (lead).
+func Shift(data []float64, periods int, fill float64) []float64 {
+	result := make([]float64, len(data))
+
+	for i := 0; i < len(data); i++ {
+		sourceIndex := i - periods
+
+		if sourceIndex < 0 || sourceIndex >= len(data) {
+			result[i] = fill
+			continue
+		}
+
+		result[i] = data[sourceIndex]
+	}
+
+	return result
+}
+
+// Diff calculates discrete difference of the data set with the given lag: out[i] = data[i+lag] - data[i],
+// i.e. every value minus the value lag periods before it (momentum). Output data length is len(data) - lag.
+// Result is float64 for any Numeric type, so the function is usable for integer inputs as well.
+func Diff[N Numeric](data []N, lag int) ([]float64, error) {
+	if lag < 1 || lag >= len(data) {
+		return nil, errors.New("stat4trading::Diff: lag should be in range [1, len(data))")
+	}
+
+	result := make([]float64, len(data)-lag)
+
+	for i := 0; i < len(result); i++ {
+		result[i] = float64(data[i+lag]) - float64(data[i])
+	}
+
+	return result, nil
+}
+
+// Apply returns a new data set where every value is transformed by fn (input data set is not modified).
+func Apply[N Numeric](data []N, fn func(N) N) []N {
+	result := make([]N, len(data))
+
+	for i := 0; i < len(data); i++ {
+		result[i] = fn(data[i])
+	}
+
+	return result
+}
+
+// Filter returns values which satisfy the predicate, together with their indexes in the original data set.
+func Filter[N Numeric](data []N, predicate func(N) bool) (values []N, indices []int) {
+	values = make([]N, 0)
+	indices = make([]int, 0)
+
+	for i := 0; i < len(data); i++ {
+		if predicate(data[i]) {
+			values = append(values, data[i])
+			indices = append(indices, i)
+		}
+	}
+
+	return values, indices
+}
+
+// CleanMode defines how CleanSeries handles invalid (NaN or ±Inf) values.
+type CleanMode string
+
+const (
+	// CleanDrop removes invalid values, so the output can be shorter than input.
+	CleanDrop CleanMode = "drop"
+	// CleanZero replaces invalid values with 0.
+	CleanZero CleanMode = "zero"
+	// CleanInterpolate replaces invalid values by linear interpolation between the closest valid neighbours.
+	// Leading (trailing) invalid values have only one valid neighbour, so they are replaced with the first (last) valid value.
+	CleanInterpolate CleanMode = "interpolate"
+)
+
+// CleanSeries handles invalid (NaN or ±Inf) values of the data set according to mode, input data set is not modified.
+// It returns an error if there are no valid values at all.
+func CleanSeries(data []float64, mode CleanMode) ([]float64, error) {
+	if mode != CleanDrop && mode != CleanZero && mode != CleanInterpolate {
+		return nil, fmt.Errorf("stat4trading::CleanSeries: unknown clean mode %q", mode)
+	}
+
+	validIndexes := make([]int, 0, len(data))
+
+	for i := 0; i < len(data); i++ {
+		if !math.IsNaN(data[i]) && !math.IsInf(data[i], 0) {
+			validIndexes = append(validIndexes, i)
+		}
+	}
+
+	if len(validIndexes) == 0 {
+		return nil, errors.New("stat4trading::CleanSeries: input data set has no valid values")
+	}
+
+	if mode == CleanDrop {
+		result := make([]float64, len(validIndexes))
+
+		for i, index := range validIndexes {
+			result[i] = data[index]
+		}
+
+		return result, nil
+	}
+
+	result := make([]float64, len(data))
+
+	if mode == CleanZero {
+		for _, index := range validIndexes {
+			result[index] = data[index]
+		}
+
+		return result, nil
+	}
+
+	// CleanInterpolate
+	firstValidIndex := validIndexes[0]
+	lastValidIndex := validIndexes[len(validIndexes)-1]
+
+	for i := 0; i < firstValidIndex; i++ {
+		result[i] = data[firstValidIndex]
+	}
+
+	for i := lastValidIndex; i < len(data); i++ {
+		result[i] = data[lastValidIndex]
+	}
+
+	for k := 0; k < len(validIndexes)-1; k++ {
+		leftIndex := validIndexes[k]
+		rightIndex := validIndexes[k+1]
+		step := (data[rightIndex] - data[leftIndex]) / float64(rightIndex-leftIndex)
+
+		for i := leftIndex; i < rightIndex; i++ {
+			result[i] = data[leftIndex] + step*float64(i-leftIndex)
+		}
+	}
+
+	return result, nil
+}
+
+// Resize linearly interpolates the data set onto a new evenly-spaced grid of targetLength points.
+// The first and the last points are preserved, so the shape of the series is kept while its length changes.
+// It is useful to compare shapes of two price patterns of different durations.
+func Resize(data []float64, targetLength int) ([]float64, error) {
+	if len(data) < 2 {
+		return nil, errors.New("stat4trading::Resize: at least 2 points are required to interpolate")
+	}
+
+	if targetLength < 2 {
+		return nil, errors.New("stat4trading::Resize: target length should be at least 2")
+	}
+
+	result := make([]float64, targetLength)
+	step := float64(len(data)-1) / float64(targetLength-1)
+
+	for i := 0; i < targetLength; i++ {
+		position := float64(i) * step
+		leftIndex := int(math.Floor(position))
+
+		if leftIndex >= len(data)-1 {
+			result[i] = data[len(data)-1]
+			continue
+		}
+
+		fraction := position - float64(leftIndex)
+		result[i] = data[leftIndex] + fraction*(data[leftIndex+1]-data[leftIndex])
+	}
+
+	return result, nil
+}
+
+func IsDataSortedASC[N Numeric](data []N) bool {
+	for i := 1; i < len(data); i++ {
+		if data[i] <= data[i-1] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func IsDataSortedDESC[N Numeric](data []N) bool {
+	for i := 1; i < len(data); i++ {
+		if data[i] >= data[i-1] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isAlmostEqual compares two floats with combined absolute and relative tolerance (see SetFloatTolerance):
+// they are equal if |v1 - v2| <= max(absoluteTolerance, relativeTolerance * max(|v1|, |v2|)).
+func isAlmostEqual(v1 float64, v2 float64) bool {
+	threshold := math.Max(floatAbsoluteTolerance, floatRelativeTolerance*math.Max(math.Abs(v1), math.Abs(v2)))
+
+	if math.Abs(v1-v2) <= threshold {
+		return true
 	}
 
 	return false
 }
+
+func mean(data []float64) float64 {
+	sum := 0.0
+
+	for i := 0; i < len(data); i++ {
+		sum += data[i]
+	}
+
+	return sum / float64(len(data))
+}
+
+// findFirstNotNaNIndex returns index of the first value which is not NaN, or -1 if there is no such value.
+// Once the first not-NaN value is taken as a starting point, all the following NaN values are skipped naturally,
+// because any comparison with NaN is false.
+func findFirstNotNaNIndex[N Numeric](data []N) int {
+	for i := 0; i < len(data); i++ {
+		if !math.IsNaN(float64(data[i])) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// calculateRSI calculates Relative Strength Index with Wilder's smoothing of average gains and losses.
+// If there were neither gains nor losses over the period, RSI is 50. Output data length is len(close) - period.
+// It is the caller's responsibility to provide enough data (at least period + 1 points).
+func calculateRSI(close []float64, period int) []float64 {
+	gains := make([]float64, len(close)-1)
+	losses := make([]float64, len(close)-1)
+
+	for i := 1; i < len(close); i++ {
+		change := close[i] - close[i-1]
+
+		if change > 0 {
+			gains[i-1] = change
+		} else {
+			losses[i-1] = -change
+		}
+	}
+
+	averageGains := wilderSmooth(gains, period)
+	averageLosses := wilderSmooth(losses, period)
+
+	result := make([]float64, len(averageGains))
+
+	for i := 0; i < len(result); i++ {
+		switch {
+		case isAlmostEqual(averageGains[i], 0.0) && isAlmostEqual(averageLosses[i], 0.0):
+			result[i] = 50
+		case isAlmostEqual(averageLosses[i], 0.0):
+			result[i] = 100
+		default:
+			result[i] = 100 - 100/(1+averageGains[i]/averageLosses[i])
+		}
+	}
+
+	return result
+}
+
+// wilderSmooth performs Wilder's smoothing (also known as RMA / SMMA):
+// the first value is a simple average of the first period points, and every next value is (previous*(period-1) + current)/period.
+// Output data length is the same as after SMA. It is the caller's responsibility to provide enough data.
+func wilderSmooth(data []float64, period int) []float64 {
+	result := make([]float64, CalculateOutputDataLengthAfterMA(len(data), period))
+	result[0] = mean(data[:period])
+
+	for i := period; i < len(data); i++ {
+		result[i-period+1] = (result[i-period]*float64(period-1) + data[i]) / float64(period)
+	}
+
+	return result
+}
+
+// calculateEMA applies EMA recursion to the whole data set (which should not be empty), seeding it with inputData[0].
+func calculateEMA(inputData []float64, alpha float64) []float64 {
+	ema := make([]float64, len(inputData))
+	ema[0] = inputData[0]
+
+	for i := 1; i < len(inputData); i++ {
+		ema[i] = alpha*inputData[i] + (1-alpha)*ema[i-1]
+	}
+
+	return ema
+}
+
+// calculateEMAFromSeed applies EMA recursion to the whole data set, using seed as ema[-1].
+func calculateEMAFromSeed(inputData []float64, alpha float64, seed float64) []float64 {
+	ema := make([]float64, len(inputData))
+	previous := seed
+
+	for i := 0; i < len(inputData); i++ {
+		ema[i] = alpha*inputData[i] + (1-alpha)*previous
+		previous = ema[i]
+	}
+
+	return ema
+}
+
+// median calculates median of the data set (which should not be empty), input data set is not modified.
+func median(data []float64) float64 {
+	sortedData := make([]float64, len(data))
+	copy(sortedData, data)
+	sort.Float64s(sortedData)
+
+	middle := len(sortedData) / 2
+
+	if len(sortedData)%2 == 0 {
+		return (sortedData[middle-1] + sortedData[middle]) / 2
+	}
+
+	return sortedData[middle]
+}
+
+// percentileOfSorted calculates percentile (0..100) of the sorted data set (which should not be empty)
+// with linear interpolation between the closest ranks.
+func percentileOfSorted(sortedData []float64, percentile float64) float64 {
+	rank := percentile / 100 * float64(len(sortedData)-1)
+	lowerIndex := int(math.Floor(rank))
+	upperIndex := int(math.Ceil(rank))
+
+	if lowerIndex == upperIndex {
+		return sortedData[lowerIndex]
+	}
+
+	fraction := rank - float64(lowerIndex)
+
+	return sortedData[lowerIndex] + fraction*(sortedData[upperIndex]-sortedData[lowerIndex])
+}
+
+// calculateRollingStdDev calculates population standard deviation of every window of width windowWidth.
+// Output data length is the same as after SMA. It is the caller's responsibility to provide enough data.
+func calculateRollingStdDev(data []float64, windowWidth int) []float64 {
+	result := make([]float64, CalculateOutputDataLengthAfterMA(len(data), windowWidth))
+
+	for i := 0; i < len(result); i++ {
+		result[i] = standardDeviation(data[i : i+windowWidth])
+	}
+
+	return result
+}
+
+// standardDeviation calculates population standard deviation of the data set (which should not be empty).
+func standardDeviation(data []float64) float64 {
+	dataMean := mean(data)
+	sum := 0.0
+
+	for i := 0; i < len(data); i++ {
+		sum += (data[i] - dataMean) * (data[i] - dataMean)
+	}
+
+	return math.Sqrt(sum / float64(len(data)))
+}
+
+// linearRegression fits a line y = ax + b to the points (x[i], y[i]) with ordinary least squares method.
+func linearRegression(x, y []float64) (LineDefinedByParameters, error) {
+	weights := make([]float64, len(x))
+
+	for i := 0; i < len(weights); i++ {
+		weights[i] = 1
+	}
+
+	return WeightedLinearRegression(x, y, weights)
+}
+
+// pointsXCoordinates extracts X coordinates of the points.
+func pointsXCoordinates(points []PointCoordinates) []float64 {
+	result := make([]float64, len(points))
+
+	for i := 0; i < len(points); i++ {
+		result[i] = points[i].X
+	}
+
+	return result
+}
+
+// distanceFromPointToLine calculates perpendicular distance from the point to the line y = ax + b.
+func distanceFromPointToLine(point PointCoordinates, line LineDefinedByParameters) float64 {
+	projection := ProjectPointOntoLine(point, line)
+
+	return math.Hypot(point.X-projection.X, point.Y-projection.Y)
+}
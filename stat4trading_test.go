@@ -0,0 +1,125 @@
+package stat4trading
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFindMaxFindMinSkipNaN(t *testing.T) {
+	nan := math.NaN()
+
+	testCases := []struct {
+		name     string
+		data     []float64
+		maxValue float64
+		maxIndex int
+		minValue float64
+		minIndex int
+	}{
+		{name: "NaN at the start", data: []float64{nan, 3, 1, 2}, maxValue: 3, maxIndex: 1, minValue: 1, minIndex: 2},
+		{name: "NaN in the middle", data: []float64{2, 1, nan, 3}, maxValue: 3, maxIndex: 3, minValue: 1, minIndex: 1},
+		{name: "NaN at the end", data: []float64{2, 3, 1, nan}, maxValue: 3, maxIndex: 1, minValue: 1, minIndex: 2},
+		{name: "single not NaN value", data: []float64{nan, 5, nan}, maxValue: 5, maxIndex: 1, minValue: 5, minIndex: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			maxValue, maxIndex, err := FindMax(tc.data)
+
+			if err != nil || maxValue != tc.maxValue || maxIndex != tc.maxIndex {
+				t.Errorf("FindMax(%v) = %v, %d, %v; want %v, %d, nil", tc.data, maxValue, maxIndex, err, tc.maxValue, tc.maxIndex)
+			}
+
+			minValue, minIndex, err := FindMin(tc.data)
+
+			if err != nil || minValue != tc.minValue || minIndex != tc.minIndex {
+				t.Errorf("FindMin(%v) = %v, %d, %v; want %v, %d, nil", tc.data, minValue, minIndex, err, tc.minValue, tc.minIndex)
+			}
+
+			minValue, minIndex, maxValue, maxIndex, err = FindMinMax(tc.data)
+
+			if err != nil || minValue != tc.minValue || minIndex != tc.minIndex || maxValue != tc.maxValue || maxIndex != tc.maxIndex {
+				t.Errorf("FindMinMax(%v) = %v, %d, %v, %d, %v; want %v, %d, %v, %d, nil",
+					tc.data, minValue, minIndex, maxValue, maxIndex, err, tc.minValue, tc.minIndex, tc.maxValue, tc.maxIndex)
+			}
+		})
+	}
+}
+
+func TestFindMaxFindMinErrors(t *testing.T) {
+	testCases := []struct {
+		name string
+		data []float64
+	}{
+		{name: "empty data set", data: []float64{}},
+		{name: "all NaN", data: []float64{math.NaN(), math.NaN(), math.NaN()}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, _, err := FindMax(tc.data); err == nil {
+				t.Errorf("FindMax(%v): expected error, got nil", tc.data)
+			}
+
+			if _, _, err := FindMin(tc.data); err == nil {
+				t.Errorf("FindMin(%v): expected error, got nil", tc.data)
+			}
+
+			if _, _, _, _, err := FindMinMax(tc.data); err == nil {
+				t.Errorf("FindMinMax(%v): expected error, got nil", tc.data)
+			}
+		})
+	}
+}
+
+func TestFindMaxFindMinInt(t *testing.T) {
+	data := []int{4, -2, 9, 0, 9, -2}
+
+	maxValue, maxIndex, err := FindMax(data)
+
+	if err != nil || maxValue != 9 || maxIndex != 2 {
+		t.Errorf("FindMax(%v) = %v, %d, %v; want 9, 2, nil", data, maxValue, maxIndex, err)
+	}
+
+	minValue, minIndex, err := FindMin(data)
+
+	if err != nil || minValue != -2 || minIndex != 1 {
+		t.Errorf("FindMin(%v) = %v, %d, %v; want -2, 1, nil", data, minValue, minIndex, err)
+	}
+
+	minValue, minIndex, maxValue, maxIndex, err = FindMinMax(data)
+
+	if err != nil || minValue != -2 || minIndex != 1 || maxValue != 9 || maxIndex != 2 {
+		t.Errorf("FindMinMax(%v) = %v, %d, %v, %d, %v; want -2, 1, 9, 2, nil", data, minValue, minIndex, maxValue, maxIndex, err)
+	}
+}
+
+func TestFindIntersectionPointOfTwoSegmentsNegativeDiscrepancy(t *testing.T) {
+	lineA := LineDefinedByTwoPoints{PointA: PointCoordinates{X: 0, Y: 0.1}, PointB: PointCoordinates{X: 3, Y: 1.4000000000000001}}
+	lineB := LineDefinedByTwoPoints{PointA: PointCoordinates{X: 0, Y: 2.242857142857143}, PointB: PointCoordinates{X: 3, Y: -1.5571428571428572}}
+
+	// Solve the system the same way FindIntersectionPointOfTwoSegments does to make sure the discrepancy is negative.
+	k := (lineA.PointB.Y - lineA.PointA.Y) / (lineA.PointB.X - lineA.PointA.X)
+	b := lineA.PointA.Y - k*lineA.PointA.X
+	m := (lineB.PointB.Y - lineB.PointA.Y) / (lineB.PointB.X - lineB.PointA.X)
+	c := lineB.PointA.Y - m*lineB.PointA.X
+	x := (c - b) / (k - m)
+	y1 := k*x + b
+	y2 := m*x + c
+
+	if y2-y1 <= 1e-18 {
+		t.Fatalf("test data should produce Y1 < Y2 by more than 1e-18, got Y1 = %v, Y2 = %v", y1, y2)
+	}
+
+	if _, found, err := FindIntersectionPointOfTwoSegments(lineA, lineB); err != nil || !found {
+		t.Fatalf("FindIntersectionPointOfTwoSegments with default self-control tolerance: got found = %v, err = %v; want true, nil", found, err)
+	}
+
+	// Self-control tolerance below the round-off error (~1e-16) makes negative discrepancy visible to self-control.
+	defer func(tolerance float64) { selfControlTolerance = tolerance }(selfControlTolerance)
+	selfControlTolerance = 1e-18
+
+	if _, found, err := FindIntersectionPointOfTwoSegments(lineA, lineB); err == nil || found {
+		t.Errorf("FindIntersectionPointOfTwoSegments: expected self-control error for negative Y1-Y2 discrepancy, got found = %v, err = %v", found, err)
+	}
+}